@@ -0,0 +1,140 @@
+package dot
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// adjacency is an index based view of a graph, including the nodes and
+// edges of all its subgraphs, shared by the graph algorithms.
+// Nodes are identified by their identifier and indexed by sequence number,
+// so every traversal is deterministic. Edges are followed in the direction
+// they were declared, also for undirected graphs.
+type adjacency struct {
+	nodes []*Node
+	index map[string]int
+	edges []*Edge
+	from  []int
+	to    []int
+	out   [][]int
+	in    [][]int
+}
+
+func newAdjacency(g *Graph) *adjacency {
+	adj := &adjacency{index: map[string]int{}}
+	for _, n := range g.allNodes() {
+		if _, dup := adj.index[n.id]; dup {
+			continue
+		}
+		adj.index[n.id] = len(adj.nodes)
+		adj.nodes = append(adj.nodes, n)
+	}
+	adj.out = make([][]int, len(adj.nodes))
+	adj.in = make([][]int, len(adj.nodes))
+
+	for _, e := range g.allEdges() {
+		u, ok := adj.index[e.from.id]
+		if !ok {
+			continue
+		}
+		v, ok := adj.index[e.to.id]
+		if !ok {
+			continue
+		}
+		i := len(adj.edges)
+		adj.edges = append(adj.edges, e)
+		adj.from = append(adj.from, u)
+		adj.to = append(adj.to, v)
+		adj.out[u] = append(adj.out[u], i)
+		adj.in[v] = append(adj.in[v], i)
+	}
+	return adj
+}
+
+// successors returns the distinct heads of the edges leaving u, ordered by index.
+func (adj *adjacency) successors(u int) []int {
+	return adj.distinct(adj.out[u], adj.to)
+}
+
+// predecessors returns the distinct tails of the edges entering v, ordered by index.
+func (adj *adjacency) predecessors(v int) []int {
+	return adj.distinct(adj.in[v], adj.from)
+}
+
+// neighbors returns the distinct nodes adjacent to u in the given direction,
+// ordered by index.
+func (adj *adjacency) neighbors(u int, dir Direction) []int {
+	switch dir {
+	case Downstream:
+		return adj.successors(u)
+	case Upstream:
+		return adj.predecessors(u)
+	}
+	all := append(append([]int{}, adj.out[u]...), adj.in[u]...)
+	seen := map[int]bool{}
+	res := []int{}
+	for i, e := range all {
+		w := adj.to[e]
+		if i >= len(adj.out[u]) {
+			w = adj.from[e]
+		}
+		if !seen[w] {
+			seen[w] = true
+			res = append(res, w)
+		}
+	}
+	sort.Ints(res)
+	return res
+}
+
+func (adj *adjacency) distinct(edges []int, end []int) []int {
+	seen := map[int]bool{}
+	res := []int{}
+	for _, i := range edges {
+		if w := end[i]; !seen[w] {
+			seen[w] = true
+			res = append(res, w)
+		}
+	}
+	sort.Ints(res)
+	return res
+}
+
+// nodeIndex returns the index of the node, or -1 if it does not belong to the graph.
+func (adj *adjacency) nodeIndex(n *Node) int {
+	if n == nil {
+		return -1
+	}
+	if i, ok := adj.index[n.id]; ok {
+		return i
+	}
+	return -1
+}
+
+// nodesAt maps indexes to nodes.
+func (adj *adjacency) nodesAt(indexes []int) []*Node {
+	res := make([]*Node, len(indexes))
+	for i, each := range indexes {
+		res[i] = adj.nodes[each]
+	}
+	return res
+}
+
+// edgesBetween returns the indexes of all the edges going from u to v.
+func (adj *adjacency) edgesBetween(u, v int) (res []int) {
+	for _, i := range adj.out[u] {
+		if adj.to[i] == v {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// errNodeNotFound reports a node that does not belong to the graph.
+func errNodeNotFound(n *Node) error {
+	if n == nil {
+		return errors.New("missing node")
+	}
+	return fmt.Errorf("node %q is not part of the graph", n.id)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 // HTML renders the provided content as graphviz HTML. Use of this
@@ -51,6 +53,68 @@ func (a *AttributesMap) Delete(key string) {
 	delete(a.attributes, key)
 }
 
+// numericValue converts an attribute value to a number, if possible.
+func numericValue(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case uint:
+		return float64(x), true
+	case float32:
+		return float64(x), true
+	case float64:
+		return x, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f, err == nil
+	case Literal:
+		f, err := strconv.ParseFloat(strings.Trim(string(x), `" `), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// appendStyle adds a style (e.g. filled, dashed) to the comma separated
+// list in the `style` attribute, unless already present.
+func appendStyle(a *AttributesMap, style string) {
+	current, _ := a.Value("style").(string)
+	for _, each := range strings.Split(current, ",") {
+		if strings.TrimSpace(each) == style {
+			return
+		}
+	}
+	if len(current) > 0 {
+		style = current + "," + style
+	}
+	a.Attr("style", style)
+}
+
+// clone returns a copy of the attributes that does not share storage
+// with the original.
+func (a AttributesMap) clone() AttributesMap {
+	c := AttributesMap{attributes: make(map[string]interface{}, len(a.attributes))}
+	for k, v := range a.attributes {
+		c.attributes[k] = v
+	}
+	return c
+}
+
+// withAttributesOf replaces all the attributes with a copy of src.
+func withAttributesOf(src AttributesMap) func(*AttributesMap) {
+	return func(am *AttributesMap) {
+		for k := range am.attributes {
+			delete(am.attributes, k)
+		}
+		for k, v := range src.attributes {
+			am.attributes[k] = v
+		}
+	}
+}
+
 func (a *AttributesMap) Write(wri io.Writer, mustBracket bool) {
 	if len(a.attributes) == 0 {
 		return
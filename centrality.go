@@ -0,0 +1,257 @@
+package dot
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Metric is a score for each node, by node identifier.
+type Metric map[string]float64
+
+// Normalize returns a copy of the metric rescaled to the range [0, 1].
+// If all the scores are equal they all become 0.
+func (m Metric) Normalize() Metric {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range m {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	res := make(Metric, len(m))
+	for k, v := range m {
+		if hi > lo {
+			res[k] = (v - lo) / (hi - lo)
+			continue
+		}
+		res[k] = 0
+	}
+	return res
+}
+
+// DegreeCentrality returns the number of edges leaving (Downstream),
+// entering (Upstream) or touching (Both) each node.
+func (g *Graph) DegreeCentrality(dir Direction) Metric {
+	adj := newAdjacency(g)
+	m := make(Metric, len(adj.nodes))
+	for u, n := range adj.nodes {
+		switch dir {
+		case Downstream:
+			m[n.id] = float64(len(adj.out[u]))
+		case Upstream:
+			m[n.id] = float64(len(adj.in[u]))
+		default:
+			m[n.id] = float64(len(adj.out[u]) + len(adj.in[u]))
+		}
+	}
+	return m
+}
+
+// BetweennessCentrality returns, for each node, the number of shortest paths
+// between other nodes passing through it (Brandes' algorithm, unweighted).
+// Edges of undirected graphs are followed both ways.
+func (g *Graph) BetweennessCentrality() Metric {
+	adj := newAdjacency(g)
+	dir := g.metricDirection()
+	n := len(adj.nodes)
+	score := make([]float64, n)
+
+	for s := 0; s < n; s++ {
+		stack := []int{}
+		preds := make([][]int, n)
+		sigma := make([]float64, n)
+		dist := make([]int, n)
+		for i := range dist {
+			dist[i] = -1
+		}
+		sigma[s], dist[s] = 1, 0
+		queue := []int{s}
+		for i := 0; i < len(queue); i++ {
+			u := queue[i]
+			stack = append(stack, u)
+			for _, v := range adj.neighbors(u, dir) {
+				if dist[v] < 0 {
+					dist[v] = dist[u] + 1
+					queue = append(queue, v)
+				}
+				if dist[v] == dist[u]+1 {
+					sigma[v] += sigma[u]
+					preds[v] = append(preds[v], u)
+				}
+			}
+		}
+
+		delta := make([]float64, n)
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, u := range preds[w] {
+				delta[u] += sigma[u] / sigma[w] * (1 + delta[w])
+			}
+			if w != s {
+				score[w] += delta[w]
+			}
+		}
+	}
+
+	m := make(Metric, n)
+	for u, node := range adj.nodes {
+		if dir == Both {
+			score[u] /= 2
+		}
+		m[node.id] = score[u]
+	}
+	return m
+}
+
+// ClosenessCentrality returns, for each node, the inverse of the average
+// distance to the nodes it can reach, scaled by the fraction of nodes it can
+// reach (Wasserman and Faust), so that disconnected graphs are supported.
+// Edges of undirected graphs are followed both ways.
+func (g *Graph) ClosenessCentrality() Metric {
+	adj := newAdjacency(g)
+	dir := g.metricDirection()
+	n := len(adj.nodes)
+	m := make(Metric, n)
+
+	for s, node := range adj.nodes {
+		dist := make([]int, n)
+		for i := range dist {
+			dist[i] = -1
+		}
+		dist[s] = 0
+		queue := []int{s}
+		total := 0
+		for i := 0; i < len(queue); i++ {
+			u := queue[i]
+			total += dist[u]
+			for _, v := range adj.neighbors(u, dir) {
+				if dist[v] < 0 {
+					dist[v] = dist[u] + 1
+					queue = append(queue, v)
+				}
+			}
+		}
+		m[node.id] = 0
+		if reached := float64(len(queue) - 1); total > 0 && n > 1 {
+			m[node.id] = reached / float64(total) * reached / float64(n-1)
+		}
+	}
+	return m
+}
+
+// PageRank returns the PageRank of each node, with the given damping factor
+// (usually 0.85), iterating until convergence or for at most the given
+// number of iterations. Rank of nodes without outgoing edges is spread evenly.
+// Edges of undirected graphs are followed both ways.
+func (g *Graph) PageRank(damping float64, iterations int) Metric {
+	adj := newAdjacency(g)
+	dir := g.metricDirection()
+	n := len(adj.nodes)
+	m := make(Metric, n)
+	if n == 0 {
+		return m
+	}
+
+	links := make([][]int, n)
+	for u := range adj.nodes {
+		links[u] = adj.neighbors(u, dir)
+	}
+	rank := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+	for it := 0; it < iterations; it++ {
+		dangling := 0.0
+		for u := range rank {
+			if len(links[u]) == 0 {
+				dangling += rank[u]
+			}
+		}
+		next := make([]float64, n)
+		for i := range next {
+			next[i] = (1-damping)/float64(n) + damping*dangling/float64(n)
+		}
+		for u, targets := range links {
+			for _, v := range targets {
+				next[v] += damping * rank[u] / float64(len(targets))
+			}
+		}
+		change := 0.0
+		for i := range rank {
+			change += math.Abs(next[i] - rank[i])
+		}
+		rank = next
+		if change < 1e-10 {
+			break
+		}
+	}
+
+	for u, node := range adj.nodes {
+		m[node.id] = rank[u]
+	}
+	return m
+}
+
+// metricDirection is the direction followed by the metrics.
+func (g *Graph) metricDirection() Direction {
+	if g.Root().graphType == Undirected.Name {
+		return Both
+	}
+	return Downstream
+}
+
+// MetricMapper sets node attributes from a normalized metric value in [0, 1].
+type MetricMapper func(a *AttributesMap, value float64)
+
+// ScaleAttr returns a MetricMapper setting the numeric attribute (e.g. width
+// or fontsize) linearly between min and max.
+func ScaleAttr(name string, min, max float64) MetricMapper {
+	return func(a *AttributesMap, value float64) {
+		a.Attr(name, strconv.FormatFloat(min+(max-min)*value, 'f', 2, 64))
+	}
+}
+
+// ColorScaleAttr returns a MetricMapper setting the color attribute (e.g.
+// fillcolor) by interpolating between two #rrggbb colors. The attribute is
+// not set if any of the colors is invalid.
+// Remember that fillcolor requires style=filled.
+func ColorScaleAttr(name, from, to string) MetricMapper {
+	r1, g1, b1, ok1 := parseHexColor(from)
+	r2, g2, b2, ok2 := parseHexColor(to)
+	mix := func(x, y uint8, t float64) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+	return func(a *AttributesMap, value float64) {
+		if !ok1 || !ok2 {
+			return
+		}
+		a.Attr(name, fmt.Sprintf("#%02x%02x%02x", mix(r1, r2, value), mix(g1, g2, value), mix(b1, b2, value)))
+	}
+}
+
+// ApplyMetric normalizes the metric and applies the mappers to the attributes
+// of every node having a score.
+func (g *Graph) ApplyMetric(m Metric, mappers ...MetricMapper) {
+	normalized := m.Normalize()
+	for _, n := range g.allNodes() {
+		value, ok := normalized[n.id]
+		if !ok {
+			continue
+		}
+		for _, each := range mappers {
+			each(n.Attrs(), value)
+		}
+	}
+}
+
+// parseHexColor parses a #rrggbb color.
+func parseHexColor(s string) (r, g, b uint8, ok bool) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), true
+}
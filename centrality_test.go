@@ -0,0 +1,99 @@
+package dot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDegreeCentrality(t *testing.T) {
+	g := NewGraph(Directed)
+	hub := g.NodeWithID("hub")
+	for _, id := range []string{"x", "y", "z"} {
+		g.Edge(hub, g.NodeWithID(id))
+	}
+	if got, want := g.DegreeCentrality(Downstream)["hub"], 3.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := g.DegreeCentrality(Upstream)["hub"], 0.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := g.DegreeCentrality(Both)["x"], 1.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestBetweennessCentrality(t *testing.T) {
+	g := NewGraph(Undirected)
+	hub := g.NodeWithID("hub")
+	for _, id := range []string{"x", "y", "z"} {
+		g.Edge(hub, g.NodeWithID(id))
+	}
+	m := g.BetweennessCentrality()
+	if got, want := m["hub"], 3.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := m["x"], 0.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestClosenessCentrality(t *testing.T) {
+	g := NewGraph(Undirected)
+	hub := g.NodeWithID("hub")
+	for _, id := range []string{"x", "y", "z"} {
+		g.Edge(hub, g.NodeWithID(id))
+	}
+	m := g.ClosenessCentrality()
+	if got, want := m["hub"], 1.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := m["x"], 0.6; math.Abs(got-want) > 1e-9 {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestPageRank(t *testing.T) {
+	g := NewGraph(Directed)
+	hub := g.NodeWithID("hub")
+	for _, id := range []string{"x", "y", "z"} {
+		g.Edge(hub, g.NodeWithID(id))
+	}
+	m := g.PageRank(0.85, 100)
+	total := 0.0
+	for _, v := range m {
+		total += v
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("got [%v] want [%v]", total, 1)
+	}
+	if m["x"] <= m["hub"] {
+		t.Errorf("leaves must rank higher than the hub: %v", m)
+	}
+}
+
+func TestApplyMetric(t *testing.T) {
+	g := NewGraph(Directed)
+	hub := g.NodeWithID("hub")
+	for _, id := range []string{"x", "y", "z"} {
+		g.Edge(hub, g.NodeWithID(id))
+	}
+	g.ApplyMetric(g.DegreeCentrality(Both),
+		ScaleAttr("width", 1, 2),
+		ColorScaleAttr("fillcolor", "#ffffff", "#ff0000"))
+
+	hub, leaf := g.FindNodeByID("hub"), g.FindNodeByID("x")
+	if got, want := hub.Value("width"), "2.00"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := hub.Value("fillcolor"), "#ff0000"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := leaf.Value("fillcolor"), "#ffffff"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	g.ApplyMetric(g.DegreeCentrality(Both), ColorScaleAttr("color", "red", "#ff0000"))
+	if got := hub.Value("color"); got != nil {
+		t.Errorf("got [%v] want [%v]", got, nil)
+	}
+}
@@ -0,0 +1,91 @@
+package dot
+
+import "math"
+
+// CircularLayout computes a circular layout of the whole graph in pure Go,
+// in the manner of the Graphviz circo program, and stores it in the same
+// attributes as LayeredLayout; edges are straight and clusters are not
+// drawn.
+//
+// The nodes of each connected component are placed on a circle in depth
+// first order, so that adjacent nodes tend to be neighbors on the circle,
+// with their outlines at least `mindist` inches apart (1 by default). The
+// circles are placed side by side. Nodes pinned with a trailing ! in `pos`
+// or `pin=true` keep their `pos`, in points, the circles are placed to
+// their right and the coordinates are not translated.
+func (g *Graph) CircularLayout() {
+	root := g.Root()
+	geo := newGeometry(root)
+	adj := geo.adj
+	attrs := geo.st.attributes(graphSelectable(root))
+	gap := 1.0 * pointsPerInch
+	if f, ok := numericValue(attrs["mindist"]); ok && f >= 0 {
+		gap = f * pointsPerInch
+	}
+
+	pinned := geo.pinned
+	fixed := emptyBox()
+	for i := range adj.nodes {
+		if p, pin, ok := initialPosition(geo.nodeAttrs[i]); ok && pin {
+			geo.center[i], pinned[i] = p, true
+			geo.keepOrigin = true
+			fixed.addBox(centered(p, geo.size[i].x, geo.size[i].y))
+		}
+	}
+
+	comps := geo.components(pinned)
+	for _, comp := range comps {
+		diameter := func(i int) float64 {
+			return math.Max(geo.size[i].x, geo.size[i].y)
+		}
+		// distance between consecutive nodes
+		chords := make([]float64, len(comp))
+		for i, each := range comp {
+			next := comp[(i+1)%len(comp)]
+			chords[i] = (diameter(each)+diameter(next))/2 + gap
+		}
+		radius := circleRadius(chords)
+		angle := math.Pi / 2
+		for i, each := range comp {
+			geo.center[each] = point{radius * math.Cos(angle), radius * math.Sin(angle)}
+			if radius > 0 {
+				angle -= 2 * math.Asin(chords[i]/(2*radius))
+			}
+		}
+	}
+	left := 0.0
+	if !fixed.empty() {
+		left = fixed.urx + gap
+	}
+	geo.pack(comps, left, gap)
+
+	geo.straightRoutes()
+	geo.write(root)
+}
+
+// circleRadius returns the radius of the circle on which consecutive
+// points at the given distances go around exactly once, found by
+// bisection; 0 for a single point.
+func circleRadius(chords []float64) float64 {
+	if len(chords) < 2 {
+		return 0
+	}
+	low, high := 0.0, 0.0
+	for _, c := range chords {
+		low = math.Max(low, c/2)
+		high += c
+	}
+	for i := 0; i < 60; i++ {
+		r := (low + high) / 2
+		turn := 0.0
+		for _, c := range chords {
+			turn += 2 * math.Asin(c/(2*r))
+		}
+		if turn > 2*math.Pi {
+			low = r
+		} else {
+			high = r
+		}
+	}
+	return high
+}
@@ -0,0 +1,66 @@
+package dot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCircularLayout(t *testing.T) {
+	g := NewGraph(Undirected)
+	nodes := []*Node{g.Node(), g.Node(), g.Node(), g.Node(), g.Node(), g.Node()}
+	for i := range nodes {
+		g.Edge(nodes[i], nodes[(i+1)%len(nodes)])
+	}
+	g.CircularLayout()
+
+	// all on a circle around the center of the drawing
+	bb := boundingBox(t, g)
+	center := point{(bb.llx + bb.urx) / 2, (bb.lly + bb.ury) / 2}
+	radius := math.Hypot(nodePos(t, nodes[0]).x-center.x, nodePos(t, nodes[0]).y-center.y)
+	for _, n := range nodes {
+		p := nodePos(t, n)
+		if got := math.Hypot(p.x-center.x, p.y-center.y); math.Abs(got-radius) > 0.1 {
+			t.Errorf("got [%v] want [%v]", got, radius)
+		}
+	}
+	// in depth first order, starting at the top, with mindist between neighbors
+	if got, want := nodePos(t, nodes[0]).x, center.x; math.Abs(got-want) > 0.1 {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := math.Hypot(nodePos(t, nodes[0]).x-nodePos(t, nodes[1]).x, nodePos(t, nodes[0]).y-nodePos(t, nodes[1]).y), 54+72.0; got < want-1 {
+		t.Errorf("got [%v] want at least [%v]", got, want)
+	}
+}
+
+func TestCircularLayoutComponents(t *testing.T) {
+	g := NewGraph(Undirected)
+	g.Attr("mindist", "0.5")
+	a, b, c := g.Node(), g.Node(), g.Node()
+	g.Edge(a, b)
+	g.CircularLayout()
+	pa, pb, pc := nodePos(t, a), nodePos(t, b), nodePos(t, c)
+	if got, want := pa.y-pb.y, 54+36.0; math.Abs(got-want) > 0.01 {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := pc.x-pa.x, 54+36.0; math.Abs(got-want) > 0.01 {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestCircularLayoutPinned(t *testing.T) {
+	g := NewGraph(Undirected)
+	nodes := []*Node{g.Node(), g.Node(), g.Node(), g.Node(), g.Node(), g.Node()}
+	for i := range nodes {
+		g.Edge(nodes[i], nodes[(i+1)%len(nodes)])
+	}
+	nodes[2].Attr("pos", "500,-20!")
+	g.CircularLayout()
+	if got, want := nodePos(t, nodes[2]), (point{500, -20}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	for _, n := range nodes {
+		if n != nodes[2] && nodePos(t, n).x < 500 {
+			t.Errorf("got [%v] want right of the pinned node", nodePos(t, n))
+		}
+	}
+}
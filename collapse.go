@@ -0,0 +1,298 @@
+package dot
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// collapsedCluster keeps what is needed to expand a collapsed cluster back.
+type collapsedCluster struct {
+	sub      *Graph
+	parent   *Graph
+	summary  int
+	edges    []ownedEdge
+	sameRank []rankMember
+}
+
+// ownedEdge is an edge together with the graph storing it
+// and its position among the edges with the same tail.
+type ownedEdge struct {
+	owner *Graph
+	index int
+	edge  Edge
+}
+
+// rankMember is a node of a rank group together with the graph storing the
+// group and its position in the group.
+type rankMember struct {
+	owner *Graph
+	group string
+	index int
+	node  Node
+}
+
+// Collapse replaces the subgraph, and all the subgraphs nested in it, with a
+// single summary node placed in its parent. The summary node takes the
+// subgraph identifier and label. Edges crossing the subgraph boundary are
+// rerouted to the summary node: a single edge keeps its attributes while
+// parallel ones are merged into one edge labeled with their count.
+// Eventually specify optional summary node attributes using the `withAttrs` functions.
+// The original subgraph is kept, so that Expand can restore it.
+// Connected subgraphs can be collapsed and expanded in any order.
+func (g *Graph) Collapse(sub *Graph, withAttrs ...func(*AttributesMap)) (*Node, error) {
+	root := g.Root()
+	if sub == nil || sub.parent == nil || !root.contains(sub) {
+		return nil, errors.New("not a subgraph of the graph")
+	}
+
+	inside := map[int]bool{}
+	sub.walkGraphs(func(each *Graph) {
+		for _, n := range each.nodes {
+			inside[n.seq] = true
+		}
+	})
+
+	// detach the edges touching the subgraph, collecting the crossing ones
+	crossing := []Edge{}
+	state := &collapsedCluster{sub: sub, parent: sub.parent}
+	root.walkGraphs(func(each *Graph) {
+		for _, oe := range each.detachEdges(func(e Edge) bool { return inside[e.from.seq] || inside[e.to.seq] }) {
+			state.edges = append(state.edges, oe)
+			if !inside[oe.edge.from.seq] || !inside[oe.edge.to.seq] {
+				crossing = append(crossing, oe.edge)
+			}
+		}
+		state.sameRank = append(state.sameRank, each.detachRankMembers(func(n Node) bool { return inside[n.seq] })...)
+	})
+
+	delete(sub.parent.subgraphs, sub.id)
+
+	summary := sub.parent.NodeWithID(sub.id, func(a *AttributesMap) {
+		if label := sub.Value("label"); label != nil {
+			a.Attr("label", label)
+		}
+		a.Attr("shape", "box3d")
+	})
+	for _, op := range withAttrs {
+		op(summary.Attrs())
+	}
+	state.summary = summary.seq
+
+	root.connectMerged(crossing, func(n *Node) *Node {
+		if inside[n.seq] {
+			return summary
+		}
+		return n
+	})
+
+	if root.collapsed == nil {
+		root.collapsed = map[string]*collapsedCluster{}
+	}
+	root.collapsed[sub.id] = state
+	return summary, nil
+}
+
+// Expand restores a subgraph collapsed by Collapse, given its identifier,
+// removing the summary node and all its edges. Edges to nodes inside other
+// subgraphs still collapsed are rerouted to their summary nodes.
+// Nested collapsed subgraphs must be expanded from the outermost one.
+func (g *Graph) Expand(id string) error {
+	root := g.Root()
+	state, ok := root.collapsed[id]
+	if !ok {
+		return fmt.Errorf("subgraph %q is not collapsed", id)
+	}
+	if !root.contains(state.parent) {
+		return fmt.Errorf("subgraph %q is inside a collapsed subgraph", id)
+	}
+
+	touchesSummary := func(e Edge) bool { return e.from.seq == state.summary || e.to.seq == state.summary }
+	root.walkGraphs(func(each *Graph) {
+		each.detachEdges(touchesSummary)
+		each.detachRankMembers(func(n Node) bool { return n.seq == state.summary })
+	})
+	delete(state.parent.nodes, id)
+	state.parent.subgraphs[id] = state.sub
+	delete(root.collapsed, id)
+
+	// the edges kept by other collapsed subgraphs for the summary node stand
+	// for the ones restored below
+	for _, other := range root.collapsed {
+		kept := []ownedEdge{}
+		for _, oe := range other.edges {
+			if !touchesSummary(oe.edge) {
+				kept = append(kept, oe)
+			}
+		}
+		other.edges = kept
+	}
+
+	// detached elements are sorted by position, so reinserting them in order
+	// restores the original sequences; edges to nodes still inside another
+	// collapsed subgraph are handed over to it and rerouted to its summary node
+	rerouted := []Edge{}
+	for _, oe := range state.edges {
+		hidden := root.collapsedContaining(oe.edge.from)
+		if hidden == nil {
+			hidden = root.collapsedContaining(oe.edge.to)
+		}
+		if hidden != nil {
+			hidden.edges = append(hidden.edges, oe)
+			sort.SliceStable(hidden.edges, func(i, j int) bool {
+				if hidden.edges[i].edge.from.seq != hidden.edges[j].edge.from.seq {
+					return hidden.edges[i].edge.from.seq < hidden.edges[j].edge.from.seq
+				}
+				return hidden.edges[i].index < hidden.edges[j].index
+			})
+			rerouted = append(rerouted, oe.edge)
+			continue
+		}
+		all := oe.owner.edgesFrom[oe.edge.from.id]
+		i := oe.index
+		if i > len(all) {
+			i = len(all)
+		}
+		all = append(all, Edge{})
+		copy(all[i+1:], all[i:])
+		all[i] = oe.edge
+		oe.owner.edgesFrom[oe.edge.from.id] = all
+	}
+	root.connectMerged(rerouted, func(n *Node) *Node {
+		if hidden := root.collapsedContaining(n); hidden != nil {
+			summary := hidden.parent.nodes[hidden.sub.id]
+			return &summary
+		}
+		return n
+	})
+	for _, m := range state.sameRank {
+		all := m.owner.sameRank[m.group]
+		i := m.index
+		if i > len(all) {
+			i = len(all)
+		}
+		all = append(all, Node{})
+		copy(all[i+1:], all[i:])
+		all[i] = m.node
+		m.owner.sameRank[m.group] = all
+	}
+	return nil
+}
+
+// Collapsed returns the identifiers of the collapsed subgraphs, sorted.
+func (g *Graph) Collapsed() []string {
+	ids := []string{}
+	for id := range g.Root().collapsed {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return naturalLess(ids[i], ids[j])
+	})
+	return ids
+}
+
+// contains reports whether sub is the graph or one of its (nested) subgraphs.
+func (g *Graph) contains(sub *Graph) bool {
+	found := false
+	g.walkGraphs(func(each *Graph) {
+		found = found || each == sub
+	})
+	return found
+}
+
+// collapsedContaining returns the collapsed subgraph hiding the node, if any.
+// Only the outermost one is considered, the others being hidden as well.
+func (g *Graph) collapsedContaining(n *Node) *collapsedCluster {
+	for _, each := range g.collapsed {
+		if g.contains(each.parent) && each.sub.contains(n.graph) {
+			return each
+		}
+	}
+	return nil
+}
+
+// connectMerged adds an edge between the endpoints of each edge, as mapped by
+// endpoint: a single edge keeps its attributes while parallel ones are merged
+// into one edge labeled with their count.
+func (g *Graph) connectMerged(edges []Edge, endpoint func(*Node) *Node) {
+	type pair struct{ from, to int }
+	nodes := map[int]*Node{}
+	merged := map[pair][]Edge{}
+	pairs := []pair{}
+	for _, e := range edges {
+		from, to := endpoint(e.from), endpoint(e.to)
+		key := pair{from: from.seq, to: to.seq}
+		nodes[from.seq], nodes[to.seq] = from, to
+		if _, ok := merged[key]; !ok {
+			pairs = append(pairs, key)
+		}
+		merged[key] = append(merged[key], e)
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		if pairs[i].from != pairs[j].from {
+			return pairs[i].from < pairs[j].from
+		}
+		return pairs[i].to < pairs[j].to
+	})
+	for _, key := range pairs {
+		all := merged[key]
+		if len(all) == 1 {
+			g.Edge(nodes[key.from], nodes[key.to], withAttributesOf(all[0].AttributesMap))
+			continue
+		}
+		g.Edge(nodes[key.from], nodes[key.to], WithLabel(strconv.Itoa(len(all))))
+	}
+}
+
+// detachEdges removes from the graph the edges matching drop and returns them.
+func (g *Graph) detachEdges(drop func(Edge) bool) (detached []ownedEdge) {
+	for from, all := range g.edgesFrom {
+		kept := []Edge{}
+		for i, e := range all {
+			if drop(e) {
+				detached = append(detached, ownedEdge{owner: g, index: i, edge: e})
+				continue
+			}
+			kept = append(kept, e)
+		}
+		if len(kept) == 0 {
+			delete(g.edgesFrom, from)
+			continue
+		}
+		g.edgesFrom[from] = kept
+	}
+	sort.Slice(detached, func(i, j int) bool {
+		if detached[i].edge.from.seq != detached[j].edge.from.seq {
+			return detached[i].edge.from.seq < detached[j].edge.from.seq
+		}
+		return detached[i].index < detached[j].index
+	})
+	return detached
+}
+
+// detachRankMembers removes from the rank groups the nodes matching drop and returns them.
+func (g *Graph) detachRankMembers(drop func(Node) bool) (detached []rankMember) {
+	for group, members := range g.sameRank {
+		kept := []Node{}
+		for i, n := range members {
+			if drop(n) {
+				detached = append(detached, rankMember{owner: g, group: group, index: i, node: n})
+				continue
+			}
+			kept = append(kept, n)
+		}
+		if len(kept) == 0 {
+			delete(g.sameRank, group)
+			continue
+		}
+		g.sameRank[group] = kept
+	}
+	sort.Slice(detached, func(i, j int) bool {
+		if detached[i].group != detached[j].group {
+			return detached[i].group < detached[j].group
+		}
+		return detached[i].index < detached[j].index
+	})
+	return detached
+}
@@ -0,0 +1,142 @@
+package dot
+
+import "testing"
+
+func TestCollapse(t *testing.T) {
+	g := NewGraph(Directed)
+	outside := g.Node(WithLabel("outside"))
+	other := g.Node(WithLabel("other"))
+	cluster := g.NewSubgraph()
+	cluster.Label("backend")
+	one := cluster.Node(WithLabel("one"))
+	two := cluster.Node(WithLabel("two"))
+	g.Edge(outside, one)
+	g.Edge(outside, two)
+	g.Edge(one, two)
+	g.Edge(two, other).Attr("color", "red")
+
+	summary, err := g.Collapse(cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := summary.Value("label"), "backend"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(g.subgraphs), 0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	in := g.FindEdges(*outside, *summary)
+	if got, want := len(in), 1; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := in[0].Value("label"), "2"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	out := g.FindEdges(*summary, *other)
+	if got, want := len(out), 1; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := out[0].Value("color"), "red"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestCollapseExpandRoundTrip(t *testing.T) {
+	g := NewGraph(Directed)
+	outside := g.Node(WithLabel("outside"))
+	other := g.Node(WithLabel("other"))
+	cluster := g.NewSubgraph()
+	cluster.Label("backend")
+	one := cluster.Node(WithLabel("one"))
+	two := cluster.Node(WithLabel("two"))
+	nested := cluster.NewSubgraph()
+	three := nested.Node(WithLabel("three"))
+	g.Edge(outside, one)
+	g.Edge(outside, three)
+	g.Edge(outside, other)
+	g.Edge(one, two)
+	g.Edge(two, three)
+	g.Edge(three, other).Attr("color", "red")
+	g.AddToSameRank("top", *outside, *one)
+	want := g.String()
+
+	if _, err := g.Collapse(nested); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Collapse(cluster); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(g.Collapsed()), 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if err := g.Expand(nested.id); err == nil {
+		t.Error("expected error expanding a cluster inside a collapsed one")
+	}
+	if err := g.Expand(cluster.id); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Expand(nested.id); err != nil {
+		t.Fatal(err)
+	}
+	if got := g.String(); got != want {
+		t.Errorf("got\n[%v] want\n[%v]", got, want)
+	}
+	if err := g.Expand(nested.id); err == nil {
+		t.Error("expected error expanding a cluster twice")
+	}
+}
+
+func TestCollapseConnectedClusters(t *testing.T) {
+	for _, order := range [][]int{{0, 1}, {1, 0}} {
+		g := NewGraph(Directed)
+		outside := g.Node(WithLabel("outside"))
+		first, second := g.NewSubgraph(), g.NewSubgraph()
+		a1, a2 := first.Node(), first.Node()
+		b1, b2 := second.Node(), second.Node()
+		g.Edge(a1, b1).Attr("color", "red")
+		g.Edge(a2, b1)
+		g.Edge(b2, a1)
+		g.Edge(a1, a2)
+		g.Edge(outside, b2)
+		want := g.String()
+
+		clusters := []*Graph{first, second}
+		summaries := []*Node{}
+		for _, each := range clusters {
+			summary, err := g.Collapse(each)
+			if err != nil {
+				t.Fatal(err)
+			}
+			summaries = append(summaries, summary)
+		}
+		if got, want := len(g.FindEdges(*summaries[0], *summaries[1])), 1; got != want {
+			t.Errorf("got [%v] want [%v]", got, want)
+		}
+		if got, want := len(g.FindEdges(*summaries[1], *summaries[0])), 1; got != want {
+			t.Errorf("got [%v] want [%v]", got, want)
+		}
+
+		if err := g.Expand(clusters[order[0]].id); err != nil {
+			t.Fatal(err)
+		}
+		// the edges between the clusters are rerouted to the summary node left
+		if order[0] == 0 {
+			rerouted := g.FindEdges(*a1, *summaries[1])
+			if len(rerouted) != 1 || rerouted[0].Value("color") != "red" {
+				t.Errorf("got [%v] want a red edge", rerouted)
+			}
+		} else {
+			rerouted := g.FindEdges(*summaries[0], *b1)
+			if len(rerouted) != 1 || rerouted[0].Value("label") != "2" {
+				t.Errorf("got [%v] want two merged edges", rerouted)
+			}
+		}
+		if err := g.Expand(clusters[order[1]].id); err != nil {
+			t.Fatal(err)
+		}
+		if got := g.String(); got != want {
+			t.Errorf("got\n[%v] want\n[%v]", got, want)
+		}
+	}
+}
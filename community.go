@@ -0,0 +1,176 @@
+package dot
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Communities detects communities with the Louvain method, maximizing the
+// modularity of the undirected view of the graph, where each edge counts
+// as a link of weight 1 whatever its direction. Nodes are visited in an order
+// shuffled with the given seed, so the same seed gives the same result.
+// Communities, and the nodes within them, are ordered by creation.
+func (g *Graph) Communities(seed int64) [][]*Node {
+	adj := newAdjacency(g)
+	level := newLouvainLevel(len(adj.nodes))
+	for e := range adj.edges {
+		level.link(adj.from[e], adj.to[e], 1)
+	}
+
+	rnd := rand.New(rand.NewSource(seed))
+	// membership of the original nodes
+	member := make([]int, len(adj.nodes))
+	for i := range member {
+		member[i] = i
+	}
+	for {
+		community, moved := level.optimize(rnd)
+		if !moved {
+			break
+		}
+		for i := range member {
+			member[i] = community[member[i]]
+		}
+		level = level.aggregate(community)
+	}
+
+	groups := map[int][]int{}
+	keys := []int{}
+	for u, c := range member {
+		if _, ok := groups[c]; !ok {
+			keys = append(keys, c)
+		}
+		groups[c] = append(groups[c], u)
+	}
+	res := make([][]*Node, len(keys))
+	for i, k := range keys {
+		res[i] = adj.nodesAt(groups[k])
+	}
+	return res
+}
+
+// ClusterCommunities returns a copy of the graph where the nodes of each
+// community found by Communities are placed into their own new cluster.
+// Existing subgraphs left without nodes are removed.
+// Eventually specify optional cluster attributes using the `withAttrs` functions.
+func (g *Graph) ClusterCommunities(seed int64, withAttrs ...func(*AttributesMap)) *Graph {
+	c := g.regroup(g.Communities(seed), withAttrs...)
+	c.pruneEmptySubgraphs()
+	return c
+}
+
+// louvainLevel is a weighted undirected graph. Self loops are stored once.
+type louvainLevel struct {
+	links  []map[int]float64
+	degree []float64
+	total  float64
+}
+
+func newLouvainLevel(n int) *louvainLevel {
+	l := &louvainLevel{links: make([]map[int]float64, n), degree: make([]float64, n)}
+	for i := range l.links {
+		l.links[i] = map[int]float64{}
+	}
+	return l
+}
+
+func (l *louvainLevel) link(u, v int, w float64) {
+	l.links[u][v] += w
+	if u != v {
+		l.links[v][u] += w
+	}
+	l.degree[u] += w
+	l.degree[v] += w
+	l.total += 2 * w
+}
+
+// neighbors returns the linked nodes in index order.
+func (l *louvainLevel) neighbors(u int) []int {
+	res := make([]int, 0, len(l.links[u]))
+	for v := range l.links[u] {
+		res = append(res, v)
+	}
+	sort.Ints(res)
+	return res
+}
+
+// optimize moves nodes between communities while modularity improves. It
+// returns the communities, renumbered from 0, and whether any node moved.
+func (l *louvainLevel) optimize(rnd *rand.Rand) ([]int, bool) {
+	n := len(l.links)
+	community := make([]int, n)
+	tot := make([]float64, n)
+	for i := range community {
+		community[i] = i
+		tot[i] = l.degree[i]
+	}
+	if l.total == 0 {
+		return community, false
+	}
+
+	order := rnd.Perm(n)
+	moved := false
+	for improved := true; improved; {
+		improved = false
+		for _, u := range order {
+			current := community[u]
+			tot[current] -= l.degree[u]
+
+			weights := map[int]float64{}
+			candidates := []int{current}
+			for _, v := range l.neighbors(u) {
+				if v == u {
+					continue
+				}
+				c := community[v]
+				if _, ok := weights[c]; !ok && c != current {
+					candidates = append(candidates, c)
+				}
+				weights[c] += l.links[u][v]
+			}
+
+			best, bestGain := current, weights[current]-tot[current]*l.degree[u]/l.total
+			for _, c := range candidates[1:] {
+				gain := weights[c] - tot[c]*l.degree[u]/l.total
+				if gain > bestGain+1e-12 {
+					best, bestGain = c, gain
+				}
+			}
+			community[u] = best
+			tot[best] += l.degree[u]
+			if best != current {
+				improved, moved = true, true
+			}
+		}
+	}
+
+	// renumber the communities by smallest member
+	renumber := map[int]int{}
+	for u, c := range community {
+		if _, ok := renumber[c]; !ok {
+			renumber[c] = len(renumber)
+		}
+		community[u] = renumber[c]
+	}
+	return community, moved
+}
+
+// aggregate returns the level where each community becomes a single node.
+func (l *louvainLevel) aggregate(community []int) *louvainLevel {
+	size := 0
+	for _, c := range community {
+		if c+1 > size {
+			size = c + 1
+		}
+	}
+	next := newLouvainLevel(size)
+	for u := range l.links {
+		for _, v := range l.neighbors(u) {
+			if v < u {
+				continue
+			}
+			next.link(community[u], community[v], l.links[u][v])
+		}
+	}
+	return next
+}
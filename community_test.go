@@ -0,0 +1,76 @@
+package dot
+
+import "testing"
+
+func TestCommunities(t *testing.T) {
+	g := NewGraph(Directed)
+	a1, a2, a3 := g.NodeWithID("a1"), g.NodeWithID("a2"), g.NodeWithID("a3")
+	b1, b2, b3 := g.NodeWithID("b1"), g.NodeWithID("b2"), g.NodeWithID("b3")
+	g.Edge(a1, a2)
+	g.Edge(a2, a3)
+	g.Edge(a3, a1)
+	g.Edge(b1, b2)
+	g.Edge(b2, b3)
+	g.Edge(b3, b1)
+	g.Edge(a3, b1)
+	comms := g.Communities(42)
+	if got, want := len(comms), 2; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := nodeIDs(comms[0]), "a1,a2,a3"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := nodeIDs(comms[1]), "b1,b2,b3"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestCommunitiesDeterministic(t *testing.T) {
+	g := NewGraph(Directed)
+	a1, a2, a3 := g.NodeWithID("a1"), g.NodeWithID("a2"), g.NodeWithID("a3")
+	b1, b2, b3 := g.NodeWithID("b1"), g.NodeWithID("b2"), g.NodeWithID("b3")
+	g.Edge(a1, a2)
+	g.Edge(a2, a3)
+	g.Edge(a3, a1)
+	g.Edge(b1, b2)
+	g.Edge(b2, b3)
+	g.Edge(b3, b1)
+	g.Edge(a3, b1)
+	for seed := int64(0); seed < 10; seed++ {
+		first, second := g.Communities(seed), g.Communities(seed)
+		if len(first) != len(second) {
+			t.Fatalf("seed %d: got [%v] want [%v]", seed, len(second), len(first))
+		}
+		for i := range first {
+			if got, want := nodeIDs(second[i]), nodeIDs(first[i]); got != want {
+				t.Errorf("seed %d: got [%v] want [%v]", seed, got, want)
+			}
+		}
+	}
+}
+
+func TestClusterCommunities(t *testing.T) {
+	g := NewGraph(Directed)
+	a1, a2, a3 := g.NodeWithID("a1"), g.NodeWithID("a2"), g.NodeWithID("a3")
+	b1, b2, b3 := g.NodeWithID("b1"), g.NodeWithID("b2"), g.NodeWithID("b3")
+	g.Edge(a1, a2)
+	g.Edge(a2, a3)
+	g.Edge(a3, a1)
+	g.Edge(b1, b2)
+	g.Edge(b2, b3)
+	g.Edge(b3, b1)
+	g.Edge(a3, b1)
+	old := g.NewSubgraph()
+	old.NodeWithID("alone")
+
+	c := g.ClusterCommunities(1, func(a *AttributesMap) { a.Attr("style", "dashed") })
+	if got, want := len(c.subgraphs), 3; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if _, ok := c.subgraphs[old.id]; ok {
+		t.Error("emptied subgraph must be removed")
+	}
+	if got, want := len(c.nodes), 0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
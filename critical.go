@@ -0,0 +1,181 @@
+package dot
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// NodeCost returns the cost of a node, e.g. the duration of a task.
+type NodeCost func(*Node) float64
+
+// NodeAttrCost returns a NodeCost reading the numeric node attribute with the
+// given name (e.g. "duration"), or fallback if it is missing or not a number.
+func NodeAttrCost(name string, fallback float64) NodeCost {
+	return func(n *Node) float64 {
+		if f, ok := numericValue(n.Value(name)); ok {
+			return f
+		}
+		return fallback
+	}
+}
+
+// Timing is the schedule of a node computed by CriticalPath.
+type Timing struct {
+	Node           *Node
+	Duration       float64
+	EarliestStart  float64
+	EarliestFinish float64
+	LatestStart    float64
+	LatestFinish   float64
+	Slack          float64
+}
+
+// Critical reports whether the node cannot be delayed without delaying the whole graph.
+func (t Timing) Critical() bool {
+	return t.Slack < slackTolerance
+}
+
+// Schedule is the result of a critical path analysis.
+type Schedule struct {
+	// Timings of all nodes, in topological order.
+	Timings []Timing
+	// Critical is the longest path through the graph.
+	Critical      []*Node
+	CriticalEdges []*Edge
+	// Length is the total duration of the graph.
+	Length float64
+
+	index map[string]int
+}
+
+const slackTolerance = 1e-9
+
+// CriticalPath computes earliest and latest start and finish times, the slack
+// of every node and the critical path of a DAG. Durations come from the
+// nodes, the edges, or both (e.g. task durations and transfer delays);
+// a nil cost counts as zero. Among equally long paths the one through
+// earlier created nodes wins. If the graph is not acyclic a *CycleError is returned.
+func (g *Graph) CriticalPath(nodeCost NodeCost, edgeCost EdgeCost) (*Schedule, error) {
+	if nodeCost == nil {
+		nodeCost = func(*Node) float64 { return 0 }
+	}
+	if edgeCost == nil {
+		edgeCost = func(*Edge) float64 { return 0 }
+	}
+	adj := newAdjacency(g)
+	order, ok := adj.topologicalOrder()
+	if !ok {
+		return nil, &CycleError{Cycles: adj.nodeCycles()}
+	}
+
+	t := make([]Timing, len(adj.nodes))
+	for _, u := range order {
+		t[u].Node = adj.nodes[u]
+		t[u].Duration = nodeCost(adj.nodes[u])
+		for _, e := range adj.in[u] {
+			if start := t[adj.from[e]].EarliestFinish + edgeCost(adj.edges[e]); start > t[u].EarliestStart {
+				t[u].EarliestStart = start
+			}
+		}
+		t[u].EarliestFinish = t[u].EarliestStart + t[u].Duration
+	}
+
+	s := &Schedule{index: map[string]int{}}
+	for _, each := range t {
+		s.Length = math.Max(s.Length, each.EarliestFinish)
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		u := order[i]
+		t[u].LatestFinish = s.Length
+		for _, e := range adj.out[u] {
+			if finish := t[adj.to[e]].LatestStart - edgeCost(adj.edges[e]); finish < t[u].LatestFinish {
+				t[u].LatestFinish = finish
+			}
+		}
+		t[u].LatestStart = t[u].LatestFinish - t[u].Duration
+		t[u].Slack = t[u].LatestStart - t[u].EarliestStart
+	}
+
+	for _, u := range order {
+		s.index[adj.nodes[u].id] = len(s.Timings)
+		s.Timings = append(s.Timings, t[u])
+	}
+
+	// walk the critical path from the first critical source
+	current := -1
+	for _, u := range order {
+		if len(adj.in[u]) == 0 && t[u].Critical() {
+			current = u
+			break
+		}
+	}
+	for current >= 0 {
+		s.Critical = append(s.Critical, adj.nodes[current])
+		next := -1
+		for _, e := range adj.out[current] {
+			v := adj.to[e]
+			tight := math.Abs(t[current].EarliestFinish+edgeCost(adj.edges[e])-t[v].EarliestStart) < slackTolerance
+			if tight && t[v].Critical() && (next < 0 || v < adj.to[next]) {
+				next = e
+			}
+		}
+		if next < 0 {
+			break
+		}
+		s.CriticalEdges = append(s.CriticalEdges, adj.edges[next])
+		current = adj.to[next]
+	}
+	return s, nil
+}
+
+// Timing returns the timing of the given node.
+func (s *Schedule) Timing(n *Node) (Timing, bool) {
+	i, ok := s.index[n.id]
+	if !ok {
+		return Timing{}, false
+	}
+	return s.Timings[i], true
+}
+
+// scheduleSuffix matches the schedule appended to a label by Annotate.
+var scheduleSuffix = regexp.MustCompile(`\n[-0-9.]+-[-0-9.]+ \(slack [-0-9.]+\)$`)
+
+// Annotate appends the schedule of each node to its label, as
+// `label\nES-EF (slack S)`, and applies the given attributes to the nodes
+// and edges of the critical path. Without attributes they are painted red.
+// A schedule already appended, by an earlier call, is replaced.
+// Nodes with HTML or Literal labels keep their label.
+func (s *Schedule) Annotate(withAttrs ...func(*AttributesMap)) {
+	if len(withAttrs) == 0 {
+		withAttrs = []func(*AttributesMap){func(a *AttributesMap) {
+			a.Attr("color", "red")
+			a.Attr("fontcolor", "red")
+		}}
+	}
+	for _, each := range s.Timings {
+		label, ok := each.Node.Value("label").(string)
+		if !ok {
+			continue
+		}
+		label = scheduleSuffix.ReplaceAllString(label, "")
+		each.Node.Attr("label", fmt.Sprintf("%s\n%s-%s (slack %s)", label,
+			formatFloat(each.EarliestStart), formatFloat(each.EarliestFinish), formatFloat(each.Slack)))
+	}
+	for _, n := range s.Critical {
+		for _, op := range withAttrs {
+			op(n.Attrs())
+		}
+	}
+	for _, e := range s.CriticalEdges {
+		for _, op := range withAttrs {
+			op(e.Attrs())
+		}
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,85 @@
+package dot
+
+import "testing"
+
+func TestCriticalPath(t *testing.T) {
+	g := NewGraph(Directed)
+	checkout := g.NodeWithID("checkout", func(a *AttributesMap) { a.Attr("duration", 1) })
+	build := g.NodeWithID("build", func(a *AttributesMap) { a.Attr("duration", "5") })
+	lint := g.NodeWithID("lint", func(a *AttributesMap) { a.Attr("duration", 2) })
+	deploy := g.NodeWithID("deploy", func(a *AttributesMap) { a.Attr("duration", 1.5) })
+	g.Edge(checkout, build)
+	g.Edge(checkout, lint)
+	g.Edge(build, deploy)
+	g.Edge(lint, deploy)
+	s, err := g.CriticalPath(NodeAttrCost("duration", 0), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Length, 7.5; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := nodeIDs(s.Critical), "checkout,build,deploy"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(s.CriticalEdges), 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	timing, _ := s.Timing(lint)
+	if got, want := timing.Slack, 3.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := timing.LatestStart, 4.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if timing.Critical() {
+		t.Error("lint must not be critical")
+	}
+}
+
+func TestCriticalPathEdgeCost(t *testing.T) {
+	g := NewGraph(Directed)
+	checkout := g.NodeWithID("checkout", func(a *AttributesMap) { a.Attr("duration", 1) })
+	build := g.NodeWithID("build", func(a *AttributesMap) { a.Attr("duration", 5) })
+	lint := g.NodeWithID("lint", func(a *AttributesMap) { a.Attr("duration", 2) })
+	deploy := g.NodeWithID("deploy", func(a *AttributesMap) { a.Attr("duration", 1.5) })
+	g.Edge(checkout, build)
+	g.Edge(checkout, lint)
+	g.Edge(build, deploy)
+	g.Edge(lint, deploy).Attr("delay", 10)
+
+	s, err := g.CriticalPath(NodeAttrCost("duration", 0), AttrCost("delay", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := nodeIDs(s.Critical), "checkout,lint,deploy"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestScheduleAnnotate(t *testing.T) {
+	g := NewGraph(Directed)
+	checkout := g.NodeWithID("checkout", func(a *AttributesMap) { a.Attr("duration", 1) })
+	build := g.NodeWithID("build", func(a *AttributesMap) { a.Attr("duration", 5) })
+	lint := g.NodeWithID("lint", func(a *AttributesMap) { a.Attr("duration", 2) })
+	g.Edge(checkout, build)
+	g.Edge(checkout, lint)
+	s, _ := g.CriticalPath(NodeAttrCost("duration", 0), nil)
+	s.Annotate()
+
+	if got, want := lint.Value("label"), "lint\n1-3 (slack 3)"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := build.Value("color"), "red"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	lint.Attr("duration", 6)
+	s, _ = g.CriticalPath(NodeAttrCost("duration", 0), nil)
+	s.Annotate()
+	s.Annotate()
+	if got, want := lint.Value("label"), "lint\n1-7 (slack 0)"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
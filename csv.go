@@ -0,0 +1,400 @@
+package dot
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions configures the edge list and node list CSV importers and
+// exporters, and the delimiter of the adjacency matrices. The zero value
+// uses the default column names.
+type CSVOptions struct {
+	// Source and Target are the edge list columns with the node
+	// identifiers, "source" and "target" by default.
+	Source, Target string
+	// EdgeAttributes maps edge list columns to edge attributes.
+	// If nil, every other column maps to the attribute with the same name.
+	EdgeAttributes map[string]string
+	// ID is the node list column with the node identifiers, "id" by default.
+	ID string
+	// Cluster is the node list column with the label of the cluster
+	// of each node; none if empty.
+	Cluster string
+	// NodeAttributes maps node list columns to node attributes.
+	// If nil, every other column maps to the attribute with the same name.
+	NodeAttributes map[string]string
+	// Comma is the field delimiter, ',' by default.
+	Comma rune
+}
+
+func (o CSVOptions) withDefaults() CSVOptions {
+	if len(o.Source) == 0 {
+		o.Source = "source"
+	}
+	if len(o.Target) == 0 {
+		o.Target = "target"
+	}
+	if len(o.ID) == 0 {
+		o.ID = "id"
+	}
+	if o.Comma == 0 {
+		o.Comma = ','
+	}
+	return o
+}
+
+// ReadCSV builds a graph from an edge list CSV and an optional node list CSV
+// (nodes can be nil), both with a header row. Nodes are created in the order
+// of the node list, then in the order they first appear in the edge list.
+// Nodes with a value in the cluster column are created in a cluster with
+// that label. Empty cells do not set attributes.
+func ReadCSV(edges, nodes io.Reader, opts CSVOptions, options ...GraphOption) (*Graph, error) {
+	opts = opts.withDefaults()
+	g := NewGraph(options...)
+	byID := map[string]*Node{}
+	clusters := map[string]*Graph{}
+
+	if nodes != nil {
+		header, rows, err := readCSV(nodes, opts.Comma)
+		if err != nil {
+			return nil, err
+		}
+		id, ok := header[opts.ID]
+		if !ok {
+			return nil, fmt.Errorf("node list has no %q column", opts.ID)
+		}
+		cluster, hasCluster := header[opts.Cluster]
+		columns := csvColumns(header, opts.NodeAttributes, opts.ID, opts.Cluster)
+		for i, row := range rows {
+			if len(row[id]) == 0 {
+				return nil, fmt.Errorf("node list row %d has no identifier", i+2)
+			}
+			if _, dup := byID[row[id]]; dup {
+				return nil, fmt.Errorf("node list row %d: duplicate node %q", i+2, row[id])
+			}
+			owner := g
+			if hasCluster && len(row[cluster]) > 0 {
+				if owner = clusters[row[cluster]]; owner == nil {
+					owner = g.NewSubgraph()
+					owner.Label(row[cluster])
+					clusters[row[cluster]] = owner
+				}
+			}
+			byID[row[id]] = owner.NodeWithID(row[id], csvAttributes(row, columns))
+		}
+	}
+
+	header, rows, err := readCSV(edges, opts.Comma)
+	if err != nil {
+		return nil, err
+	}
+	source, ok := header[opts.Source]
+	if !ok {
+		return nil, fmt.Errorf("edge list has no %q column", opts.Source)
+	}
+	target, ok := header[opts.Target]
+	if !ok {
+		return nil, fmt.Errorf("edge list has no %q column", opts.Target)
+	}
+	columns := csvColumns(header, opts.EdgeAttributes, opts.Source, opts.Target)
+	node := func(id string) *Node {
+		n, ok := byID[id]
+		if !ok {
+			n = g.NodeWithID(id)
+			byID[id] = n
+		}
+		return n
+	}
+	for i, row := range rows {
+		if len(row[source]) == 0 || len(row[target]) == 0 {
+			return nil, fmt.Errorf("edge list row %d has no source or target", i+2)
+		}
+		g.Edge(node(row[source]), node(row[target]), csvAttributes(row, columns))
+	}
+	return g, nil
+}
+
+// WriteEdgeCSV writes the edges as a CSV edge list with a header row: the
+// source and target node identifiers followed by the attribute columns,
+// sorted by name.
+func (g *Graph) WriteEdgeCSV(w io.Writer, opts CSVOptions) error {
+	opts = opts.withDefaults()
+	edges := g.allEdges()
+	attrs := make([]map[string]interface{}, len(edges))
+	for i, e := range edges {
+		attrs[i] = e.attributes
+	}
+	columns := csvExportColumns(attrs, opts.EdgeAttributes)
+
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.Comma
+	header := append([]string{opts.Source, opts.Target}, columns.names...)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, e := range edges {
+		row := append([]string{e.from.id, e.to.id}, columns.values(e.attributes)...)
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteNodeCSV writes the nodes as a CSV node list with a header row: the
+// node identifier, the label of the innermost subgraph containing the node
+// if a cluster column is set, then the attribute columns sorted by name.
+func (g *Graph) WriteNodeCSV(w io.Writer, opts CSVOptions) error {
+	opts = opts.withDefaults()
+	nodes := g.allNodes()
+	attrs := make([]map[string]interface{}, len(nodes))
+	for i, n := range nodes {
+		attrs[i] = n.attributes
+	}
+	columns := csvExportColumns(attrs, opts.NodeAttributes)
+
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.Comma
+	header := []string{opts.ID}
+	if len(opts.Cluster) > 0 {
+		header = append(header, opts.Cluster)
+	}
+	if err := cw.Write(append(header, columns.names...)); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		row := []string{n.id}
+		if len(opts.Cluster) > 0 {
+			cluster := ""
+			if n.graph != g {
+				cluster = n.graph.id
+				if label, ok := n.graph.Value("label").(string); ok {
+					cluster = label
+				}
+			}
+			row = append(row, cluster)
+		}
+		if err := cw.Write(append(row, columns.values(n.attributes)...)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadAdjacencyMatrix builds a graph from a CSV adjacency matrix: a header
+// row and a first column with the node identifiers, in the same order.
+// Empty and zero cells mean no edge. With an empty weight attribute name a
+// cell counts the parallel edges between two nodes, otherwise each non zero
+// cell becomes one edge with the cell value as weight attribute.
+// For Undirected graphs only the upper triangle of the matrix is read.
+// Of the options, only the delimiter is used.
+func ReadAdjacencyMatrix(r io.Reader, weight string, opts CSVOptions, options ...GraphOption) (*Graph, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = opts.withDefaults().Comma
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty adjacency matrix")
+	}
+	ids := records[0][1:]
+	if len(records)-1 != len(ids) {
+		return nil, fmt.Errorf("adjacency matrix has %d rows for %d columns", len(records)-1, len(ids))
+	}
+
+	g := NewGraph(options...)
+	nodes := make([]*Node, len(ids))
+	seen := map[string]bool{}
+	for i, id := range ids {
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate node %q", id)
+		}
+		seen[id] = true
+		nodes[i] = g.NodeWithID(id)
+	}
+
+	undirected := g.graphType == Undirected.Name
+	for i, row := range records[1:] {
+		if len(row) != len(ids)+1 {
+			return nil, fmt.Errorf("adjacency matrix row %d has %d cells", i+2, len(row))
+		}
+		if row[0] != ids[i] {
+			return nil, fmt.Errorf("adjacency matrix row %d is %q, want %q", i+2, row[0], ids[i])
+		}
+		for j, cell := range row[1:] {
+			if undirected && j < i {
+				continue
+			}
+			cell = strings.TrimSpace(cell)
+			value, err := strconv.ParseFloat(cell, 64)
+			if len(cell) == 0 || (err == nil && value == 0) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("adjacency matrix cell %q,%q: %v", ids[i], ids[j], err)
+			}
+			if len(weight) > 0 {
+				g.Edge(nodes[i], nodes[j], func(a *AttributesMap) { a.Attr(weight, cell) })
+				continue
+			}
+			count, err := strconv.Atoi(cell)
+			if err != nil || count < 0 {
+				return nil, fmt.Errorf("adjacency matrix cell %q,%q: %q is not an edge count", ids[i], ids[j], cell)
+			}
+			for k := 0; k < count; k++ {
+				g.Edge(nodes[i], nodes[j])
+			}
+		}
+	}
+	return g, nil
+}
+
+// WriteAdjacencyMatrix writes the graph as a CSV adjacency matrix, with the
+// node identifiers in the header row and first column. With an empty weight
+// attribute name a cell counts the edges between two nodes, otherwise it
+// sums their weight attribute (1 when missing or not a number).
+// The matrix of Undirected graphs is symmetric.
+// Of the options, only the delimiter is used.
+func (g *Graph) WriteAdjacencyMatrix(w io.Writer, weight string, opts CSVOptions) error {
+	adj := newAdjacency(g)
+	if len(adj.nodes) != len(g.allNodes()) {
+		return fmt.Errorf("node identifiers are not unique")
+	}
+	size := len(adj.nodes)
+	matrix := make([][]float64, size)
+	for i := range matrix {
+		matrix[i] = make([]float64, size)
+	}
+	undirected := g.Root().graphType == Undirected.Name
+	for i, e := range adj.edges {
+		value := 1.0
+		if len(weight) > 0 {
+			if f, ok := numericValue(e.Value(weight)); ok {
+				value = f
+			}
+		}
+		u, v := adj.from[i], adj.to[i]
+		matrix[u][v] += value
+		if undirected && u != v {
+			matrix[v][u] += value
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.withDefaults().Comma
+	header := []string{""}
+	for _, n := range adj.nodes {
+		header = append(header, n.id)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for i, n := range adj.nodes {
+		row := []string{n.id}
+		for _, value := range matrix[i] {
+			row = append(row, formatFloat(value))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// readCSV reads all the records, returning the column indexes by name.
+func readCSV(r io.Reader, comma rune) (map[string]int, [][]string, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("missing header row")
+	}
+	header := map[string]int{}
+	for i, name := range records[0] {
+		header[strings.TrimSpace(name)] = i
+	}
+	return header, records[1:], nil
+}
+
+// csvColumns returns the attribute names by column index, for the mapped
+// columns or, with a nil mapping, for all the columns but the excluded ones.
+func csvColumns(header map[string]int, mapping map[string]string, exclude ...string) map[int]string {
+	columns := map[int]string{}
+	if mapping != nil {
+		for column, attr := range mapping {
+			if i, ok := header[column]; ok {
+				columns[i] = attr
+			}
+		}
+		return columns
+	}
+	for column, i := range header {
+		columns[i] = column
+	}
+	for _, each := range exclude {
+		if i, ok := header[each]; ok && len(each) > 0 {
+			delete(columns, i)
+		}
+	}
+	return columns
+}
+
+func csvAttributes(row []string, columns map[int]string) func(*AttributesMap) {
+	return func(a *AttributesMap) {
+		for i, name := range columns {
+			if len(row[i]) > 0 {
+				a.Attr(name, row[i])
+			}
+		}
+	}
+}
+
+// csvExport maps attributes to the columns of an export.
+type csvExport struct {
+	names []string
+	attrs []string
+}
+
+// csvExportColumns returns the columns for the mapping, from column name to
+// attribute, or for all the attributes found when the mapping is nil.
+func csvExportColumns(all []map[string]interface{}, mapping map[string]string) csvExport {
+	res := csvExport{}
+	if mapping == nil {
+		mapping = map[string]string{}
+		for _, attrs := range all {
+			for name := range attrs {
+				mapping[name] = name
+			}
+		}
+	}
+	for column := range mapping {
+		res.names = append(res.names, column)
+	}
+	sort.Strings(res.names)
+	for _, column := range res.names {
+		res.attrs = append(res.attrs, mapping[column])
+	}
+	return res
+}
+
+func (c csvExport) values(attrs map[string]interface{}) []string {
+	row := make([]string, len(c.attrs))
+	for i, name := range c.attrs {
+		if v, ok := attrs[name]; ok {
+			row[i] = attributeText(v)
+		}
+	}
+	return row
+}
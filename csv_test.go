@@ -0,0 +1,134 @@
+package dot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	edges := "source,target,weight,kind\na,b,3,\nb,c,,sync\n"
+	nodes := "id,team,shape\nb,core,box\nc,core,\nd,,circle\n"
+	g, err := ReadCSV(strings.NewReader(edges), strings.NewReader(nodes), CSVOptions{
+		Cluster:        "team",
+		EdgeAttributes: map[string]string{"weight": "weight", "kind": "label"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := nodeIDs(g.allNodes()), "b,c,d,a"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	core, ok := g.FindSubgraphByLabel("core")
+	if !ok {
+		t.Fatal("missing cluster")
+	}
+	if got, want := len(core.nodes), 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := g.FindNodeByID("b").Value("shape"), "box"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got := g.FindNodeByID("c").Value("shape"); got != nil {
+		t.Errorf("got [%v] want [%v]", got, nil)
+	}
+	ab := g.FindEdges(*g.FindNodeByID("a"), *g.FindNodeByID("b"))
+	if got, want := len(ab), 1; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := ab[0].Value("weight"), "3"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got := ab[0].Value("label"); got != nil {
+		t.Errorf("got [%v] want [%v]", got, nil)
+	}
+
+	if _, err := ReadCSV(strings.NewReader("from,to\na,b\n"), nil, CSVOptions{}); err == nil {
+		t.Error("expected error for missing columns")
+	}
+}
+
+func TestWriteEdgeAndNodeCSV(t *testing.T) {
+	g := NewGraph(Directed)
+	a := g.NodeWithID("a")
+	sub := g.NewSubgraph()
+	sub.Label("core")
+	b := sub.NodeWithID("b", func(a *AttributesMap) { a.Attr("shape", "box") })
+	g.Edge(a, b, func(a *AttributesMap) { a.Attr("weight", 2.5) })
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteEdgeCSV(buf, CSVOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "source,target,weight\na,b,2.5\n"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	buf.Reset()
+	if err := g.WriteNodeCSV(buf, CSVOptions{Cluster: "team"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "id,team,label,shape\na,,a,\nb,core,b,box\n"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestAdjacencyMatrix(t *testing.T) {
+	src := ",a,b,c\na,0,2,0\nb,0,0,1\nc,1,,0\n"
+	g, err := ReadAdjacencyMatrix(strings.NewReader(src), "", CSVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(g.allEdges()), 4; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	buf := new(bytes.Buffer)
+	if err := g.WriteAdjacencyMatrix(buf, "", CSVOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), ",a,b,c\na,0,2,0\nb,0,0,1\nc,1,0,0\n"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestAdjacencyMatrixUndirectedWeights(t *testing.T) {
+	src := ",a,b\na,0,1.5\nb,1.5,0\n"
+	g, err := ReadAdjacencyMatrix(strings.NewReader(src), "weight", CSVOptions{}, Undirected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edges := g.allEdges()
+	if got, want := len(edges), 1; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := edges[0].Value("weight"), "1.5"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	buf := new(bytes.Buffer)
+	if err := g.WriteAdjacencyMatrix(buf, "weight", CSVOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), src; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	if _, err := ReadAdjacencyMatrix(strings.NewReader(",a,b\na,0,x\nb,0,0\n"), "", CSVOptions{}, Directed); err == nil {
+		t.Error("expected error for invalid cell")
+	}
+}
+
+func TestAdjacencyMatrixDelimiter(t *testing.T) {
+	src := ";a;b\na;0;1.5\nb;0;0\n"
+	opts := CSVOptions{Comma: ';'}
+	g, err := ReadAdjacencyMatrix(strings.NewReader(src), "weight", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := g.WriteAdjacencyMatrix(buf, "weight", opts); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), src; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
@@ -0,0 +1,261 @@
+package dot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// cytoscapeShapes maps dot shapes to Cytoscape.js node shapes.
+var cytoscapeShapes = map[string]string{
+	"box":           "rectangle",
+	"rect":          "rectangle",
+	"rectangle":     "rectangle",
+	"square":        "rectangle",
+	"ellipse":       "ellipse",
+	"oval":          "ellipse",
+	"circle":        "ellipse",
+	"doublecircle":  "ellipse",
+	"point":         "ellipse",
+	"diamond":       "diamond",
+	"hexagon":       "hexagon",
+	"octagon":       "octagon",
+	"triangle":      "triangle",
+	"star":          "star",
+	"cylinder":      "barrel",
+	"parallelogram": "rhomboid",
+}
+
+// cytoscapeReserved are the data fields used by Cytoscape.js itself;
+// attributes with these names are exported with a dot_ prefix.
+var cytoscapeReserved = map[string]bool{"id": true, "source": true, "target": true, "parent": true}
+
+type cytoscapeElements struct {
+	Nodes []cytoscapeElement `json:"nodes"`
+	Edges []cytoscapeElement `json:"edges"`
+}
+
+type cytoscapeElement struct {
+	Data     map[string]interface{} `json:"data"`
+	Position *cytoscapePosition     `json:"position,omitempty"`
+	Style    map[string]interface{} `json:"style,omitempty"`
+}
+
+type cytoscapePosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// WriteCytoscape writes the graph as Cytoscape.js elements JSON, an object
+// with "nodes" and "edges". Subgraphs become compound nodes, set as "parent"
+// of their nodes and subgraphs. Attributes are written in "data" and
+// translated to the element "style". With positions, the `pos` attribute of
+// the nodes (in points, as computed by a layout) is written as "position",
+// flipping the y axis. Node and subgraph identifiers must be unique; edges
+// are numbered, with a suffix when the number is taken by a node.
+func (g *Graph) WriteCytoscape(w io.Writer, positions bool) error {
+	st := newStyler(g.Root())
+	elements := cytoscapeElements{Nodes: []cytoscapeElement{}, Edges: []cytoscapeElement{}}
+	ids := map[string]bool{}
+	top := 0.0
+	if bb, ok := parseFloats(g.Value("bb")); ok && len(bb) == 4 {
+		top = bb[3]
+	}
+
+	var err error
+	g.walkGraphs(func(each *Graph) {
+		if each == g || err != nil {
+			return
+		}
+		if ids[each.id] {
+			err = fmt.Errorf("subgraph identifier %q is not unique", each.id)
+			return
+		}
+		ids[each.id] = true
+		attrs := st.attributes(graphSelectable(each))
+		el := cytoscapeElement{Data: cytoscapeData(each.id, attrs), Style: map[string]interface{}{}}
+		if each.parent != g {
+			el.Data["parent"] = each.parent.id
+		}
+		label, ok := plainLabel(attrs["label"])
+		if !ok || len(label) == 0 {
+			label = each.id
+		}
+		el.Style["label"] = label
+		cytoscapeBoxStyle(el.Style, attrs)
+		elements.Nodes = append(elements.Nodes, el)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, n := range g.allNodes() {
+		if ids[n.id] {
+			return fmt.Errorf("node identifier %q is not unique", n.id)
+		}
+		ids[n.id] = true
+		attrs := effectiveNodeAttributes(n, st)
+		el := cytoscapeElement{Data: cytoscapeData(n.id, attrs), Style: map[string]interface{}{}}
+		if n.graph != g {
+			el.Data["parent"] = n.graph.id
+		}
+		cytoscapeNodeStyle(el.Style, attrs)
+		if positions {
+			if pos, ok := parseFloats(attrs["pos"]); ok && len(pos) == 2 {
+				el.Position = &cytoscapePosition{X: pos[0], Y: top - pos[1]}
+			}
+		}
+		elements.Nodes = append(elements.Nodes, el)
+	}
+
+	for i, e := range g.allEdges() {
+		id := fmt.Sprintf("e%d", i)
+		for suffix := 1; ids[id]; suffix++ {
+			id = fmt.Sprintf("e%d_%d", i, suffix)
+		}
+		ids[id] = true
+		attrs := effectiveEdgeAttributes(e, st)
+		el := cytoscapeElement{Data: cytoscapeData(id, attrs), Style: map[string]interface{}{}}
+		el.Data["source"] = e.from.id
+		el.Data["target"] = e.to.id
+		cytoscapeEdgeStyle(el.Style, e, attrs)
+		elements.Edges = append(elements.Edges, el)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(elements)
+}
+
+// cytoscapeData returns the element data: its identifier and attributes.
+func cytoscapeData(id string, attrs map[string]interface{}) map[string]interface{} {
+	data := map[string]interface{}{"id": id}
+	for k, v := range attrs {
+		if cytoscapeReserved[k] {
+			k = "dot_" + k
+		}
+		switch x := v.(type) {
+		case HTML:
+			data[k] = string(x)
+		case Literal:
+			data[k] = string(x)
+		default:
+			data[k] = v
+		}
+	}
+	return data
+}
+
+func cytoscapeNodeStyle(style map[string]interface{}, attrs map[string]interface{}) {
+	if label, ok := plainLabel(attrs["label"]); ok {
+		style["label"] = label
+	}
+	shape := cytoscapeShapes[shapeOf(attrs)]
+	if len(shape) == 0 {
+		shape = "rectangle"
+	}
+	if shape == "rectangle" && styleWords(attrs["style"])["rounded"] {
+		shape = "round-rectangle"
+	}
+	style["shape"] = shape
+	if w, ok := numericValue(attrs["width"]); ok {
+		style["width"] = w * 72
+	}
+	if h, ok := numericValue(attrs["height"]); ok {
+		style["height"] = h * 72
+	}
+	if size, ok := numericValue(attrs["fontsize"]); ok {
+		style["font-size"] = size
+	}
+	if font, ok := attrs["fontname"]; ok {
+		style["font-family"] = fmt.Sprint(font)
+	}
+	cytoscapeBoxStyle(style, attrs)
+}
+
+// cytoscapeBoxStyle sets the colors and border of a node or compound node.
+func cytoscapeBoxStyle(style map[string]interface{}, attrs map[string]interface{}) {
+	s := newExportStyle(attrs, map[string]bool{})
+	if len(s.fill) > 0 {
+		style["background-color"] = s.fill
+	}
+	if len(s.stroke) > 0 {
+		style["border-color"] = s.stroke
+	}
+	if len(s.font) > 0 {
+		style["color"] = s.font
+	}
+	if s.width > 0 {
+		style["border-width"] = s.width
+	} else if s.bold {
+		style["border-width"] = 2
+	}
+	switch {
+	case s.dashed:
+		style["border-style"] = "dashed"
+	case s.dotted:
+		style["border-style"] = "dotted"
+	}
+	if s.invisible {
+		style["visibility"] = "hidden"
+	}
+}
+
+func cytoscapeEdgeStyle(style map[string]interface{}, e *Edge, attrs map[string]interface{}) {
+	s := newExportStyle(attrs, map[string]bool{})
+	style["curve-style"] = "bezier"
+	if label, ok := plainLabel(attrs["label"]); ok && len(label) > 0 {
+		style["label"] = label
+	}
+	switch edgeDir(e, attrs) {
+	case "forward":
+		style["target-arrow-shape"] = "triangle"
+	case "back":
+		style["source-arrow-shape"] = "triangle"
+	case "both":
+		style["source-arrow-shape"] = "triangle"
+		style["target-arrow-shape"] = "triangle"
+	}
+	if len(s.stroke) > 0 {
+		style["line-color"] = s.stroke
+		style["source-arrow-color"] = s.stroke
+		style["target-arrow-color"] = s.stroke
+	}
+	if len(s.font) > 0 {
+		style["color"] = s.font
+	}
+	if s.width > 0 {
+		style["width"] = s.width
+	} else if s.bold {
+		style["width"] = 2
+	}
+	switch {
+	case s.dashed:
+		style["line-style"] = "dashed"
+	case s.dotted:
+		style["line-style"] = "dotted"
+	}
+	if s.invisible {
+		style["visibility"] = "hidden"
+	}
+}
+
+// parseFloats parses a comma separated list of numbers, like the `pos` and
+// `bb` attributes; a trailing ! (pinned position) is ignored.
+func parseFloats(v interface{}) ([]float64, bool) {
+	s := strings.TrimSuffix(strings.Trim(attrString(v), `" `), "!")
+	if len(s) == 0 {
+		return nil, false
+	}
+	res := []float64{}
+	for _, each := range strings.Split(s, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(each), 64)
+		if err != nil {
+			return nil, false
+		}
+		res = append(res, f)
+	}
+	return res, true
+}
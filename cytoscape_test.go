@@ -0,0 +1,104 @@
+package dot
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteCytoscape(t *testing.T) {
+	g := NewGraph(Directed)
+	g.Attr("bb", "0,0,200,100")
+	sub := g.NewSubgraph()
+	sub.Attr("label", "Work")
+	a := g.NodeWithID("a", func(a *AttributesMap) {
+		a.Attr("shape", "box")
+		a.Attr("style", "rounded,filled")
+		a.Attr("fillcolor", "yellow")
+		a.Attr("id", "first")
+		a.Attr("pos", "10,20!")
+	})
+	b := sub.NodeWithID("b")
+	g.Edge(a, b, WithLabel("go"), func(a *AttributesMap) { a.Attr("style", "dashed") })
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteCytoscape(buf, true); err != nil {
+		t.Fatal(err)
+	}
+	elements := cytoscapeElements{}
+	if err := json.Unmarshal(buf.Bytes(), &elements); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(elements.Nodes), 3; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	cluster, first, second := elements.Nodes[0], elements.Nodes[1], elements.Nodes[2]
+	if got, want := cluster.Style["label"], "Work"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := second.Data["parent"], "cluster_1"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := first.Data["dot_id"], "first"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := first.Style["shape"], "round-rectangle"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := first.Style["background-color"], "yellow"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := *first.Position, (cytoscapePosition{X: 10, Y: 80}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if second.Position != nil {
+		t.Errorf("got [%v] want [%v]", second.Position, nil)
+	}
+
+	edge := elements.Edges[0]
+	if got, want := edge.Data["source"], "a"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := edge.Style["line-style"], "dashed"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := edge.Style["target-arrow-shape"], "triangle"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestWriteCytoscapeWithoutPositions(t *testing.T) {
+	g := NewGraph(Directed)
+	g.Node(func(a *AttributesMap) { a.Attr("pos", "1,2") })
+	buf := new(bytes.Buffer)
+	if err := g.WriteCytoscape(buf, false); err != nil {
+		t.Fatal(err)
+	}
+	elements := cytoscapeElements{}
+	if err := json.Unmarshal(buf.Bytes(), &elements); err != nil {
+		t.Fatal(err)
+	}
+	if got := elements.Nodes[0].Position; got != nil {
+		t.Errorf("got [%v] want [%v]", got, nil)
+	}
+}
+
+func TestWriteCytoscapeEdgeIDs(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b := g.NodeWithID("e0"), g.NodeWithID("e1")
+	g.Edge(a, b)
+	g.Edge(b, a)
+	buf := new(bytes.Buffer)
+	if err := g.WriteCytoscape(buf, false); err != nil {
+		t.Fatal(err)
+	}
+	elements := cytoscapeElements{}
+	if err := json.Unmarshal(buf.Bytes(), &elements); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"e0_1", "e1_1"} {
+		if got := elements.Edges[i].Data["id"]; got != want {
+			t.Errorf("got [%v] want [%v]", got, want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package dot
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var d2Directions = map[string]string{"TB": "down", "BT": "up", "LR": "right", "RL": "left"}
+
+// d2Shapes maps dot shapes to D2 shapes.
+var d2Shapes = map[string]string{
+	"box":           "rectangle",
+	"rect":          "rectangle",
+	"rectangle":     "rectangle",
+	"square":        "square",
+	"ellipse":       "oval",
+	"oval":          "oval",
+	"circle":        "circle",
+	"doublecircle":  "circle",
+	"point":         "circle",
+	"diamond":       "diamond",
+	"hexagon":       "hexagon",
+	"parallelogram": "parallelogram",
+	"cylinder":      "cylinder",
+	"note":          "page",
+	"folder":        "package",
+	"tab":           "package",
+	"cds":           "step",
+	"plaintext":     "text",
+	"plain":         "text",
+	"none":          "text",
+}
+
+// WriteD2 writes the graph as a D2 diagram: nodes and clusters become
+// shapes and containers, edges become connections, with labels, colors,
+// line styles, links and tooltips.
+// Attributes set by the stylesheet in use, if any, are translated too.
+// The report lists the attributes that have no D2 equivalent.
+func (g *Graph) WriteD2(w io.Writer) (*ExportReport, error) {
+	d := &d2Writer{b: new(strings.Builder), top: g, ids: containerIDs(g), st: newStyler(g.Root()), report: &ExportReport{}}
+	g.walk(d)
+	_, err := io.WriteString(w, d.b.String())
+	return d.report, err
+}
+
+type d2Writer struct {
+	b      *strings.Builder
+	top    *Graph
+	ids    map[*Graph]string
+	st     *styler
+	report *ExportReport
+	indent string
+}
+
+func (d *d2Writer) enterGraph(g *Graph) {
+	attrs := d.st.attributes(graphSelectable(g))
+	translated := map[string]bool{"label": true}
+	label, ok := plainLabel(attrs["label"])
+	if !ok {
+		translated["label"] = false
+	}
+
+	if g == d.top {
+		if dir, ok := d2Directions[fmt.Sprint(attrs["rankdir"])]; ok {
+			translated["rankdir"] = true
+			fmt.Fprintf(d.b, "direction: %s\n", dir)
+		}
+		if len(label) > 0 {
+			fmt.Fprintf(d.b, "title: %s {\n  shape: text\n  near: top-center\n}\n", strconv.Quote(label))
+		}
+		d.report.untranslated(graphElement(g), attrs, translated)
+		return
+	}
+
+	if len(label) == 0 {
+		label = g.id
+	}
+	fmt.Fprintf(d.b, "%s%s: %s {\n", d.indent, d.ids[g], strconv.Quote(label))
+	d.indent += "  "
+	for _, each := range d.style(attrs, translated) {
+		fmt.Fprintf(d.b, "%s%s\n", d.indent, each)
+	}
+	d.report.untranslated(graphElement(g), attrs, translated)
+}
+
+func (d *d2Writer) nodes(g *Graph, nodes []*Node) {
+	for _, n := range nodes {
+		attrs := effectiveNodeAttributes(n, d.st)
+		translated := map[string]bool{"label": true, "shape": true}
+		label, ok := plainLabel(attrs["label"])
+		if !ok {
+			label = n.id
+			translated["label"] = false
+		}
+
+		props := []string{}
+		shape := shapeOf(attrs)
+		if each, ok := d2Shapes[shape]; ok {
+			if each != "rectangle" {
+				props = append(props, "shape: "+each)
+			}
+		} else {
+			translated["shape"] = false
+		}
+		if shape == "doublecircle" {
+			props = append(props, "style.double-border: true")
+		}
+		props = append(props, d.style(attrs, translated)...)
+		if url, ok := attrs["URL"]; ok {
+			translated["URL"] = true
+			props = append(props, "link: "+strconv.Quote(fmt.Sprint(url)))
+		}
+		if tooltip, ok := attrs["tooltip"]; ok {
+			translated["tooltip"] = true
+			props = append(props, "tooltip: "+strconv.Quote(fmt.Sprint(tooltip)))
+		}
+
+		fmt.Fprintf(d.b, "%sn%d: %s", d.indent, n.seq, strconv.Quote(label))
+		d.block(props)
+		d.report.untranslated("node "+n.id, attrs, translated)
+	}
+}
+
+// style returns the D2 style properties for the colors and line style of an element.
+func (d *d2Writer) style(attrs map[string]interface{}, translated map[string]bool) []string {
+	s := newExportStyle(attrs, translated)
+	props := []string{}
+	if len(s.fill) > 0 {
+		props = append(props, "style.fill: "+strconv.Quote(s.fill))
+	}
+	if len(s.stroke) > 0 {
+		props = append(props, "style.stroke: "+strconv.Quote(s.stroke))
+	}
+	if len(s.font) > 0 {
+		props = append(props, "style.font-color: "+strconv.Quote(s.font))
+	}
+	if s.width > 0 {
+		props = append(props, fmt.Sprintf("style.stroke-width: %d", d2Clamp(s.width, 1, 15)))
+	} else if s.bold {
+		props = append(props, "style.stroke-width: 3")
+	}
+	switch {
+	case s.dashed:
+		props = append(props, "style.stroke-dash: 5")
+	case s.dotted:
+		props = append(props, "style.stroke-dash: 2")
+	}
+	if s.rounded {
+		props = append(props, "style.border-radius: 8")
+	}
+	if s.invisible {
+		props = append(props, "style.opacity: 0")
+	}
+	return props
+}
+
+func (d *d2Writer) edges(g *Graph, groups [][]*Edge) {
+	for _, group := range groups {
+		for _, e := range group {
+			attrs := effectiveEdgeAttributes(e, d.st)
+			translated := map[string]bool{"label": true, "dir": true, "arrowhead": attrs["arrowhead"] == "none"}
+
+			from, to := e.from, e.to
+			arrow := "--"
+			switch edgeDir(e, attrs) {
+			case "forward":
+				arrow = "->"
+			case "back":
+				arrow = "<-"
+			case "both":
+				arrow = "<->"
+			}
+			fmt.Fprintf(d.b, "%s%s %s %s", d.indent, d.path(g, from), arrow, d.path(g, to))
+			if label, ok := plainLabel(attrs["label"]); !ok {
+				translated["label"] = false
+			} else if len(label) > 0 {
+				fmt.Fprintf(d.b, ": %s", strconv.Quote(label))
+			}
+			d.block(d.style(attrs, translated))
+			d.report.untranslated(e.element(), attrs, translated)
+		}
+	}
+}
+
+func (d *d2Writer) sameRank(g *Graph, groups [][]*Node) {
+	d.report.sameRank(g, len(groups))
+}
+
+func (d *d2Writer) leaveGraph(g *Graph) {
+	if g == d.top {
+		return
+	}
+	d.indent = d.indent[2:]
+	fmt.Fprintf(d.b, "%s}\n", d.indent)
+}
+
+// block ends the current line, writing the properties in braces if any.
+func (d *d2Writer) block(props []string) {
+	if len(props) == 0 {
+		fmt.Fprintln(d.b)
+		return
+	}
+	fmt.Fprintln(d.b, " {")
+	for _, each := range props {
+		fmt.Fprintf(d.b, "%s  %s\n", d.indent, each)
+	}
+	fmt.Fprintf(d.b, "%s}\n", d.indent)
+}
+
+// path returns the key of the node as seen from the container of graph g,
+// going up with _ when the node is outside of it.
+func (d *d2Writer) path(g *Graph, n *Node) string {
+	scope, target := d.containers(g), d.containers(n.graph)
+	i := 0
+	for i < len(scope) && i < len(target) && scope[i] == target[i] {
+		i++
+	}
+	keys := []string{}
+	for range scope[i:] {
+		keys = append(keys, "_")
+	}
+	keys = append(keys, target[i:]...)
+	return strings.Join(append(keys, fmt.Sprintf("n%d", n.seq)), ".")
+}
+
+// containers returns the keys of the subgraphs from the top graph down to g.
+func (d *d2Writer) containers(g *Graph) []string {
+	keys := []string{}
+	for ; g != nil && g != d.top; g = g.parent {
+		keys = append([]string{d.ids[g]}, keys...)
+	}
+	return keys
+}
+
+func d2Clamp(f float64, min, max int) int {
+	v := int(f + 0.5)
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
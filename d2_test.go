@@ -0,0 +1,81 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteD2(t *testing.T) {
+	g := NewGraph(Directed)
+	g.Attr("rankdir", "LR")
+	g.Attr("label", "Flow")
+	a := g.Node(WithLabel("start"), func(a *AttributesMap) {
+		a.Attr("shape", "circle")
+		a.Attr("fontname", "Arial")
+	})
+	sub := g.NewSubgraph()
+	sub.Attr("label", "Work")
+	sub.Attr("bgcolor", "#eeeeee")
+	b := sub.Node(WithLabel("run\nfast"), func(a *AttributesMap) {
+		a.Attr("shape", "cylinder")
+		a.Attr("style", "filled,dashed")
+		a.Attr("fillcolor", "yellow")
+		a.Attr("URL", "https://example.com")
+	})
+	c := sub.Node(WithLabel("end"), func(a *AttributesMap) { a.Attr("shape", "box") })
+	g.Edge(a, b, WithLabel("go"), func(a *AttributesMap) {
+		a.Attr("style", "dotted")
+		a.Attr("color", "red")
+	})
+	sub.Edge(b, c, func(a *AttributesMap) { a.Attr("dir", "both") })
+
+	buf := new(bytes.Buffer)
+	report, err := g.WriteD2(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `direction: right
+title: "Flow" {
+  shape: text
+  near: top-center
+}
+cluster_2: "Work" {
+  style.fill: "#eeeeee"
+  n4: "end"
+  n3: "run\nfast" {
+    shape: cylinder
+    style.fill: "yellow"
+    style.stroke-dash: 5
+    link: "https://example.com"
+  }
+  n3 <-> n4
+}
+n1: "start" {
+  shape: circle
+}
+n1 -> cluster_2.n3: "go" {
+  style.stroke: "red"
+  style.stroke-dash: 2
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := report.String(), "node n1: fontname=Arial\n"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestD2PathOutsideScope(t *testing.T) {
+	g := NewGraph(Directed)
+	one := g.NewSubgraph()
+	two := g.NewSubgraph()
+	a := one.Node()
+	b := one.Node()
+	two.Edge(a, b)
+
+	d := &d2Writer{top: g, ids: containerIDs(g)}
+	if got, want := d.path(two, a), "_.cluster_1.n3"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
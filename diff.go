@@ -0,0 +1,346 @@
+package dot
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// AttributeChange describes an attribute whose value differs between two graphs.
+// Old is nil when the attribute was added, New is nil when it was removed.
+type AttributeChange struct {
+	Name string
+	Old  interface{}
+	New  interface{}
+}
+
+// String returns the change as `name: old -> new`.
+func (c AttributeChange) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Name, valueString(c.Old), valueString(c.New))
+}
+
+// NodeChange describes a node present in both graphs whose attributes
+// or subgraph membership differ.
+type NodeChange struct {
+	ID         string
+	Attributes []AttributeChange
+	// OldSubgraph and NewSubgraph are the keys of the subgraphs holding
+	// the node; an empty key means the top-level graph.
+	OldSubgraph string
+	NewSubgraph string
+}
+
+// Moved reports whether the node changed subgraph.
+func (c NodeChange) Moved() bool {
+	return c.OldSubgraph != c.NewSubgraph
+}
+
+// EdgeKey identifies an edge by the identifiers of its endpoints and by its
+// position among the parallel edges connecting the same nodes.
+type EdgeKey struct {
+	From  string
+	To    string
+	Index int
+}
+
+// EdgeChange describes an edge present in both graphs with different attributes.
+type EdgeChange struct {
+	Key        EdgeKey
+	Attributes []AttributeChange
+}
+
+// SubgraphChange describes a subgraph present in both graphs whose attributes
+// or parent differ.
+type SubgraphChange struct {
+	Key        string
+	Attributes []AttributeChange
+	OldParent  string
+	NewParent  string
+}
+
+// GraphDiff is the structural difference between two graphs.
+//
+// Nodes are matched by identifier and edges by EdgeKey. Subgraphs are matched
+// by their label, falling back to their identifier when no label is set,
+// because generated identifiers (cluster_N) shift as soon as anything is
+// added before them. Use NodeWithID to get stable node identifiers.
+type GraphDiff struct {
+	Attributes     []AttributeChange
+	NodeAttributes []AttributeChange
+
+	AddedNodes   []string
+	RemovedNodes []string
+	ChangedNodes []NodeChange
+
+	AddedEdges   []EdgeKey
+	RemovedEdges []EdgeKey
+	ChangedEdges []EdgeChange
+
+	AddedSubgraphs   []string
+	RemovedSubgraphs []string
+	ChangedSubgraphs []SubgraphChange
+
+	a, b *Graph
+}
+
+// Diff compares the graph a (before) with the graph b (after).
+func Diff(a, b *Graph) *GraphDiff {
+	d := &GraphDiff{a: a, b: b}
+	d.Attributes = diffAttributes(a.AttributesMap, b.AttributesMap)
+	d.NodeAttributes = diffAttributes(a.nodeAttrs, b.nodeAttrs)
+
+	ia, ib := newDiffIndex(a), newDiffIndex(b)
+
+	for _, key := range ia.subgraphKeys {
+		sb, ok := ib.subgraphs[key]
+		if !ok {
+			d.RemovedSubgraphs = append(d.RemovedSubgraphs, key)
+			continue
+		}
+		sa := ia.subgraphs[key]
+		change := SubgraphChange{
+			Key:        key,
+			Attributes: diffAttributes(sa.AttributesMap, sb.AttributesMap),
+			OldParent:  ia.parentKey(sa),
+			NewParent:  ib.parentKey(sb),
+		}
+		if len(change.Attributes) > 0 || change.OldParent != change.NewParent {
+			d.ChangedSubgraphs = append(d.ChangedSubgraphs, change)
+		}
+	}
+	for _, key := range ib.subgraphKeys {
+		if _, ok := ia.subgraphs[key]; !ok {
+			d.AddedSubgraphs = append(d.AddedSubgraphs, key)
+		}
+	}
+
+	for _, na := range ia.nodes {
+		nb, ok := ib.nodeByID[na.id]
+		if !ok {
+			d.RemovedNodes = append(d.RemovedNodes, na.id)
+			continue
+		}
+		change := NodeChange{
+			ID:          na.id,
+			Attributes:  diffAttributes(na.AttributesMap, nb.AttributesMap),
+			OldSubgraph: ia.graphKey(na.graph),
+			NewSubgraph: ib.graphKey(nb.graph),
+		}
+		if len(change.Attributes) > 0 || change.Moved() {
+			d.ChangedNodes = append(d.ChangedNodes, change)
+		}
+	}
+	for _, nb := range ib.nodes {
+		if _, ok := ia.nodeByID[nb.id]; !ok {
+			d.AddedNodes = append(d.AddedNodes, nb.id)
+		}
+	}
+
+	for _, key := range ia.edgeKeys {
+		eb, ok := ib.edges[key]
+		if !ok {
+			d.RemovedEdges = append(d.RemovedEdges, key)
+			continue
+		}
+		if changes := diffAttributes(ia.edges[key].AttributesMap, eb.AttributesMap); len(changes) > 0 {
+			d.ChangedEdges = append(d.ChangedEdges, EdgeChange{Key: key, Attributes: changes})
+		}
+	}
+	for _, key := range ib.edgeKeys {
+		if _, ok := ia.edges[key]; !ok {
+			d.AddedEdges = append(d.AddedEdges, key)
+		}
+	}
+
+	return d
+}
+
+// Empty reports whether the two graphs are structurally identical.
+func (d *GraphDiff) Empty() bool {
+	return len(d.Attributes) == 0 && len(d.NodeAttributes) == 0 &&
+		len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 && len(d.ChangedNodes) == 0 &&
+		len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0 && len(d.ChangedEdges) == 0 &&
+		len(d.AddedSubgraphs) == 0 && len(d.RemovedSubgraphs) == 0 && len(d.ChangedSubgraphs) == 0
+}
+
+// Graph returns a new graph merging both sides of the diff, ready to be
+// rendered: additions are green, removals red and dashed, and changes are
+// orange with a tooltip listing the changed attributes.
+func (d *GraphDiff) Graph() *Graph {
+	merged := d.b.Clone()
+	ia, im := newDiffIndex(d.a), newDiffIndex(merged)
+
+	// restore removed subgraphs, parents first
+	for _, key := range ia.subgraphKeys {
+		if _, ok := im.subgraphs[key]; ok {
+			continue
+		}
+		sa := ia.subgraphs[key]
+		parent := merged
+		if p, ok := im.subgraphs[ia.parentKey(sa)]; ok {
+			parent = p
+		}
+		sub := parent.NewSubgraph()
+		sub.AttributesMap = sa.AttributesMap.clone()
+		diffStyle(&sub.AttributesMap, "red", "dashed")
+		im.subgraphs[key] = sub
+	}
+	for _, key := range d.AddedSubgraphs {
+		diffStyle(&im.subgraphs[key].AttributesMap, "green", "")
+	}
+	for _, c := range d.ChangedSubgraphs {
+		diffChanged(&im.subgraphs[c.Key].AttributesMap, c.Attributes)
+	}
+
+	// restore removed nodes in their former subgraph
+	for _, id := range d.RemovedNodes {
+		na := ia.nodeByID[id]
+		owner := merged
+		if sub, ok := im.subgraphs[ia.graphKey(na.graph)]; ok {
+			owner = sub
+		}
+		n := owner.NodeWithID(id, withAttributesOf(na.AttributesMap))
+		diffStyle(n.Attrs(), "red", "dashed")
+		im.nodeByID[id] = n
+	}
+	for _, id := range d.AddedNodes {
+		diffStyle(im.nodeByID[id].Attrs(), "green", "")
+	}
+	for _, c := range d.ChangedNodes {
+		changes := c.Attributes
+		if c.Moved() {
+			changes = append(changes, AttributeChange{Name: "subgraph", Old: c.OldSubgraph, New: c.NewSubgraph})
+		}
+		diffChanged(im.nodeByID[c.ID].Attrs(), changes)
+	}
+
+	for _, key := range d.RemovedEdges {
+		e := merged.Edge(im.nodeByID[key.From], im.nodeByID[key.To], withAttributesOf(ia.edges[key].AttributesMap))
+		diffStyle(e.Attrs(), "red", "dashed")
+	}
+	for _, key := range d.AddedEdges {
+		diffStyle(im.edges[key].Attrs(), "green", "")
+	}
+	for _, c := range d.ChangedEdges {
+		diffChanged(im.edges[c.Key].Attrs(), c.Attributes)
+	}
+
+	return merged
+}
+
+// diffStyle colors the element and eventually appends a style.
+func diffStyle(a *AttributesMap, color, style string) {
+	a.Attr("color", color)
+	a.Attr("fontcolor", color)
+	if len(style) > 0 {
+		appendStyle(a, style)
+	}
+}
+
+// diffChanged highlights an element whose attributes changed.
+func diffChanged(a *AttributesMap, changes []AttributeChange) {
+	lines := make([]string, len(changes))
+	for i, c := range changes {
+		lines[i] = c.String()
+	}
+	a.Attr("color", "orange")
+	a.Attr("penwidth", "2")
+	if len(lines) > 0 {
+		a.Attr("tooltip", strings.Join(lines, "\n"))
+	}
+}
+
+func diffAttributes(a, b AttributesMap) (changes []AttributeChange) {
+	names := map[string]bool{}
+	for k := range a.attributes {
+		names[k] = true
+	}
+	for k := range b.attributes {
+		names[k] = true
+	}
+	keys := make([]string, 0, len(names))
+	for k := range names {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		old, now := a.attributes[k], b.attributes[k]
+		if !reflect.DeepEqual(old, now) {
+			changes = append(changes, AttributeChange{Name: k, Old: old, New: now})
+		}
+	}
+	return changes
+}
+
+func valueString(v interface{}) string {
+	if v == nil {
+		return "<none>"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// diffIndex holds the lookup tables used to match elements between graphs.
+type diffIndex struct {
+	top          *Graph
+	nodes        []*Node
+	nodeByID     map[string]*Node
+	edges        map[EdgeKey]*Edge
+	edgeKeys     []EdgeKey
+	subgraphs    map[string]*Graph
+	subgraphKeys []string
+}
+
+func newDiffIndex(g *Graph) *diffIndex {
+	idx := &diffIndex{
+		top:       g,
+		nodeByID:  map[string]*Node{},
+		edges:     map[EdgeKey]*Edge{},
+		subgraphs: map[string]*Graph{},
+	}
+
+	g.walkGraphs(func(each *Graph) {
+		if each == g {
+			return
+		}
+		key := idx.graphKey(each)
+		if _, dup := idx.subgraphs[key]; !dup {
+			idx.subgraphs[key] = each
+			idx.subgraphKeys = append(idx.subgraphKeys, key)
+		}
+	})
+
+	for _, n := range g.allNodes() {
+		if _, dup := idx.nodeByID[n.id]; dup {
+			continue
+		}
+		idx.nodes = append(idx.nodes, n)
+		idx.nodeByID[n.id] = n
+	}
+
+	parallel := map[[2]string]int{}
+	for _, e := range g.allEdges() {
+		pair := [2]string{e.from.id, e.to.id}
+		key := EdgeKey{From: e.from.id, To: e.to.id, Index: parallel[pair]}
+		parallel[pair]++
+		idx.edges[key] = e
+		idx.edgeKeys = append(idx.edgeKeys, key)
+	}
+	return idx
+}
+
+// graphKey returns the matching key of a (sub)graph; empty for the top level.
+func (idx *diffIndex) graphKey(g *Graph) string {
+	if g == nil || g == idx.top {
+		return ""
+	}
+	if label, ok := g.Value("label").(string); ok && len(label) > 0 {
+		return label
+	}
+	return g.id
+}
+
+func (idx *diffIndex) parentKey(g *Graph) string {
+	return idx.graphKey(g.parent)
+}
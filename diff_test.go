@@ -0,0 +1,115 @@
+package dot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffIdentical(t *testing.T) {
+	g := NewGraph(Directed)
+	api, db := g.NodeWithID("api"), g.NodeWithID("db")
+	sub := g.NewSubgraph()
+	sub.Label("workers")
+	g.Edge(api, db)
+	g.Edge(sub.NodeWithID("job"), db)
+	if d := Diff(g, g.Clone()); !d.Empty() {
+		t.Errorf("got %+v want empty diff", d)
+	}
+}
+
+func TestDiffNodesAndEdges(t *testing.T) {
+	a := NewGraph(Directed)
+	a.Edge(a.NodeWithID("api"), a.NodeWithID("db"))
+	b := a.Clone()
+	b.FindNodeByID("db").Attr("shape", "cylinder")
+	cache := b.NodeWithID("cache")
+	b.Edge(b.FindNodeByID("api"), cache)
+
+	d := Diff(a, b)
+	if got, want := d.AddedNodes, []string{"cache"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := d.AddedEdges, []EdgeKey{{From: "api", To: "cache"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(d.ChangedNodes), 1; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := d.ChangedNodes[0].Attributes[0].String(), "shape: <none> -> cylinder"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	d = Diff(b, a)
+	if got, want := d.RemovedNodes, []string{"cache"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := d.RemovedEdges, []EdgeKey{{From: "api", To: "cache"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestDiffSubgraphMembership(t *testing.T) {
+	a := NewGraph(Directed)
+	sub := a.NewSubgraph()
+	sub.Label("workers")
+	a.Edge(a.NodeWithID("api"), a.NodeWithID("db"))
+	a.Edge(sub.NodeWithID("job"), a.FindNodeByID("db"))
+	b := NewGraph(Directed)
+	api := b.NodeWithID("api")
+	db := b.NodeWithID("db")
+	job := b.NodeWithID("job")
+	b.Edge(api, db)
+	b.Edge(job, db)
+
+	d := Diff(a, b)
+	if got, want := d.RemovedSubgraphs, []string{"workers"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(d.ChangedNodes), 1; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if c := d.ChangedNodes[0]; !c.Moved() || c.OldSubgraph != "workers" || c.NewSubgraph != "" {
+		t.Errorf("got [%+v] want job moved out of workers", c)
+	}
+}
+
+func TestDiffGraph(t *testing.T) {
+	a := NewGraph(Directed)
+	a.NodeWithID("api")
+	b := a.Clone()
+	b.NodeWithID("cache")
+	a.NodeWithID("legacy").Attr("style", "filled")
+
+	merged := Diff(a, b).Graph()
+	added := merged.FindNodeByID("cache")
+	if got, want := added.Value("color"), "green"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	removed := merged.FindNodeByID("legacy")
+	if removed == nil {
+		t.Fatal("removed node missing from merged graph")
+	}
+	if got, want := removed.Value("style"), "filled,dashed"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if b.FindNodeByID("cache").Value("color") != nil {
+		t.Error("merged graph must not alter the compared graphs")
+	}
+}
+
+func TestClone(t *testing.T) {
+	g := NewGraph(Directed)
+	n1 := g.Node(WithLabel("api"))
+	sub := g.NewSubgraph()
+	n2 := sub.Node(WithLabel("db"))
+	g.Edge(n1, n2, WithLabel("query"))
+
+	c := g.Clone()
+	if got, want := c.String(), g.String(); got != want {
+		t.Errorf("got\n[%v] want\n[%v]", got, want)
+	}
+	c.FindNodeByLabel("api").Attr("color", "red")
+	if g.FindNodeByLabel("api").Value("color") != nil {
+		t.Error("clone shares attributes with the original")
+	}
+}
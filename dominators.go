@@ -0,0 +1,186 @@
+package dot
+
+// DominatorTree holds the immediate dominators of the nodes reachable from an
+// entry node. A node d dominates n if every path from the entry to n goes
+// through d. For post-dominators paths are walked backwards from an exit node.
+type DominatorTree struct {
+	adj  *adjacency
+	root int
+	idom []int
+}
+
+// Dominators computes the dominator tree of the graph from the entry node
+// (Cooper, Harvey and Kennedy's iterative algorithm).
+func (g *Graph) Dominators(entry *Node) (*DominatorTree, error) {
+	adj := newAdjacency(g)
+	return adj.dominators(entry, adj.successors, adj.predecessors)
+}
+
+// PostDominators computes the post-dominator tree of the graph from the exit
+// node: d post-dominates n if every path from n to the exit goes through d.
+func (g *Graph) PostDominators(exit *Node) (*DominatorTree, error) {
+	adj := newAdjacency(g)
+	return adj.dominators(exit, adj.predecessors, adj.successors)
+}
+
+func (adj *adjacency) dominators(entry *Node, succ, pred func(int) []int) (*DominatorTree, error) {
+	root := adj.nodeIndex(entry)
+	if root < 0 {
+		return nil, errNodeNotFound(entry)
+	}
+
+	// number the reachable nodes in post order
+	post := make([]int, len(adj.nodes))
+	for i := range post {
+		post[i] = -1
+	}
+	visited := make([]bool, len(adj.nodes))
+	order := []int{}
+	var visit func(u int)
+	visit = func(u int) {
+		visited[u] = true
+		for _, v := range succ(u) {
+			if !visited[v] {
+				visit(v)
+			}
+		}
+		post[u] = len(order)
+		order = append(order, u)
+	}
+	visit(root)
+
+	idom := make([]int, len(adj.nodes))
+	for i := range idom {
+		idom[i] = -1
+	}
+	idom[root] = root
+
+	intersect := func(a, b int) int {
+		for a != b {
+			for post[a] < post[b] {
+				a = idom[a]
+			}
+			for post[b] < post[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+
+	for changed := true; changed; {
+		changed = false
+		// reverse post order, skipping the root
+		for i := len(order) - 2; i >= 0; i-- {
+			u := order[i]
+			next := -1
+			for _, p := range pred(u) {
+				if idom[p] < 0 {
+					continue
+				}
+				if next < 0 {
+					next = p
+					continue
+				}
+				next = intersect(p, next)
+			}
+			if next >= 0 && idom[u] != next {
+				idom[u] = next
+				changed = true
+			}
+		}
+	}
+	return &DominatorTree{adj: adj, root: root, idom: idom}, nil
+}
+
+// Immediate returns the immediate dominator of the node; nil for the entry
+// node and for unreachable nodes.
+func (d *DominatorTree) Immediate(n *Node) *Node {
+	u := d.adj.nodeIndex(n)
+	if u < 0 || u == d.root || d.idom[u] < 0 {
+		return nil
+	}
+	return d.adj.nodes[d.idom[u]]
+}
+
+// Dominates reports whether a dominates b. Every reachable node dominates itself.
+func (d *DominatorTree) Dominates(a, b *Node) bool {
+	u, v := d.adj.nodeIndex(a), d.adj.nodeIndex(b)
+	if u < 0 || v < 0 || d.idom[v] < 0 {
+		return false
+	}
+	for {
+		if v == u {
+			return true
+		}
+		if v == d.root {
+			return false
+		}
+		v = d.idom[v]
+	}
+}
+
+// Reachable reports whether the node can be reached from the entry node.
+func (d *DominatorTree) Reachable(n *Node) bool {
+	u := d.adj.nodeIndex(n)
+	return u >= 0 && d.idom[u] >= 0
+}
+
+// Unreachable returns the nodes that cannot be reached from the entry node,
+// e.g. dead code in a control-flow graph.
+func (d *DominatorTree) Unreachable() []*Node {
+	res := []*Node{}
+	for u, dom := range d.idom {
+		if dom < 0 {
+			res = append(res, d.adj.nodes[u])
+		}
+	}
+	return res
+}
+
+// HighlightUnreachable applies the given attributes to the unreachable nodes
+// and to the edges touching them, e.g. to flag dead code. Without attributes
+// they are painted gray and dashed.
+func (d *DominatorTree) HighlightUnreachable(withAttrs ...func(*AttributesMap)) {
+	if len(withAttrs) == 0 {
+		withAttrs = []func(*AttributesMap){func(a *AttributesMap) {
+			a.Attr("color", "gray")
+			a.Attr("fontcolor", "gray")
+			a.Attr("style", "dashed")
+		}}
+	}
+	for u, dom := range d.idom {
+		if dom >= 0 {
+			continue
+		}
+		for _, op := range withAttrs {
+			op(d.adj.nodes[u].Attrs())
+		}
+	}
+	for e, edge := range d.adj.edges {
+		if d.idom[d.adj.from[e]] >= 0 && d.idom[d.adj.to[e]] >= 0 {
+			continue
+		}
+		for _, op := range withAttrs {
+			op(edge.Attrs())
+		}
+	}
+}
+
+// Graph returns the dominator tree as a new directed graph, with an edge from
+// each immediate dominator to the nodes it dominates. Nodes keep their
+// identifiers and attributes; unreachable nodes are left out.
+func (d *DominatorTree) Graph() *Graph {
+	t := NewGraph(Directed)
+	nodes := make([]*Node, len(d.adj.nodes))
+	for u, n := range d.adj.nodes {
+		if d.idom[u] >= 0 {
+			nodes[u] = t.NodeWithID(n.id, withAttributesOf(n.AttributesMap))
+		}
+	}
+	for u, dom := range d.idom {
+		if dom >= 0 && u != d.root {
+			t.Edge(nodes[dom], nodes[u])
+		}
+	}
+	return t
+}
@@ -0,0 +1,94 @@
+package dot
+
+import "testing"
+
+func TestDominators(t *testing.T) {
+	g := NewGraph(Directed)
+	entry, a, b, c := g.NodeWithID("entry"), g.NodeWithID("a"), g.NodeWithID("b"), g.NodeWithID("c")
+	exit, dead := g.NodeWithID("exit"), g.NodeWithID("dead")
+	g.Edge(entry, a)
+	g.Edge(a, b)
+	g.Edge(entry, c)
+	g.Edge(c, b)
+	g.Edge(b, exit)
+	g.Edge(dead, exit)
+	d, err := g.Dominators(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.Immediate(b).ID(), "entry"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := d.Immediate(exit).ID(), "b"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if !d.Dominates(b, exit) {
+		t.Error("b must dominate exit")
+	}
+	if d.Dominates(a, b) {
+		t.Error("a must not dominate b")
+	}
+	if got, want := nodeIDs(d.Unreachable()), "dead"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	tree := d.Graph()
+	if got, want := len(tree.allNodes()), 5; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(tree.allEdges()), 4; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestHighlightUnreachable(t *testing.T) {
+	g := NewGraph(Directed)
+	entry, exit, dead := g.NodeWithID("entry"), g.NodeWithID("exit"), g.NodeWithID("dead")
+	g.Edge(entry, exit)
+	g.Edge(dead, exit)
+	d, err := g.Dominators(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.HighlightUnreachable()
+	if got, want := dead.Value("style"), "dashed"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := g.FindEdges(*dead, *exit)[0].Value("color"), "gray"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got := exit.Value("style"); got != nil {
+		t.Errorf("got [%v] want [%v]", got, nil)
+	}
+	if got := g.FindEdges(*entry, *exit)[0].Value("color"); got != nil {
+		t.Errorf("got [%v] want [%v]", got, nil)
+	}
+}
+
+func TestPostDominators(t *testing.T) {
+	g := NewGraph(Directed)
+	entry, a, b, c, exit := g.NodeWithID("entry"), g.NodeWithID("a"), g.NodeWithID("b"), g.NodeWithID("c"), g.NodeWithID("exit")
+	g.Edge(entry, a)
+	g.Edge(a, b)
+	g.Edge(entry, c)
+	g.Edge(c, b)
+	g.Edge(b, exit)
+	d, err := g.PostDominators(exit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.Immediate(entry).ID(), "b"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(d.Unreachable()), 0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestDominatorsUnknownEntry(t *testing.T) {
+	g := NewGraph(Directed)
+	g.NodeWithID("entry")
+	if _, err := g.Dominators(NewGraph().NodeWithID("x")); err == nil {
+		t.Error("expected error")
+	}
+}
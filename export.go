@@ -0,0 +1,292 @@
+package dot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExportReport lists the attributes that an exporter to another diagram
+// language could not translate.
+type ExportReport struct {
+	Untranslated []UntranslatedAttribute
+}
+
+// UntranslatedAttribute is an attribute ignored by an exporter.
+type UntranslatedAttribute struct {
+	// Element is "graph", "subgraph <id>", "node <id>" or "edge <from>-><to>"
+	Element string
+	Name    string
+	Value   interface{}
+}
+
+// Empty reports whether every attribute was translated.
+func (r *ExportReport) Empty() bool {
+	return len(r.Untranslated) == 0
+}
+
+// String returns one line per untranslated attribute.
+func (r *ExportReport) String() string {
+	b := new(strings.Builder)
+	for _, each := range r.Untranslated {
+		fmt.Fprintf(b, "%s: %s=%v\n", each.Element, each.Name, each.Value)
+	}
+	return b.String()
+}
+
+// untranslated adds the attributes of the element not listed as translated.
+func (r *ExportReport) untranslated(element string, attrs map[string]interface{}, translated map[string]bool) {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		if !translated[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.Untranslated = append(r.Untranslated, UntranslatedAttribute{Element: element, Name: name, Value: attrs[name]})
+	}
+}
+
+// effectiveAttributes returns the attributes of a node or edge, as
+// computed by the styler, merged over the global node or edge attributes
+// of the graphs containing it.
+func effectiveAttributes(g *Graph, own map[string]interface{}, global func(*Graph) AttributesMap) map[string]interface{} {
+	res := map[string]interface{}{}
+	for ; g != nil; g = g.parent {
+		for k, v := range global(g).attributes {
+			if _, ok := res[k]; !ok {
+				res[k] = v
+			}
+		}
+	}
+	for k, v := range own {
+		res[k] = v
+	}
+	return res
+}
+
+func effectiveNodeAttributes(n *Node, st *styler) map[string]interface{} {
+	return effectiveAttributes(n.graph, st.attributes(nodeSelectable(n)), func(g *Graph) AttributesMap { return g.nodeAttrs })
+}
+
+func effectiveEdgeAttributes(e *Edge, st *styler) map[string]interface{} {
+	return effectiveAttributes(e.graph, st.attributes(edgeSelectable(e)), func(g *Graph) AttributesMap { return g.edgeAttrs })
+}
+
+// exportStyle holds the colors and line style shared by the exporters.
+type exportStyle struct {
+	fill, stroke, font string
+	width              float64
+	filled, rounded    bool
+	dashed, dotted     bool
+	bold, invisible    bool
+}
+
+// newExportStyle reads the colors, the penwidth and the style of an element
+// and marks them as translated. A style word other than filled, rounded,
+// dashed, dotted, bold, solid and invis leaves `style` untranslated.
+func newExportStyle(attrs map[string]interface{}, translated map[string]bool) exportStyle {
+	s := exportStyle{}
+	for _, each := range []string{"fillcolor", "bgcolor", "color", "pencolor", "fontcolor", "penwidth", "style"} {
+		translated[each] = true
+	}
+	for word := range styleWords(attrs["style"]) {
+		switch word {
+		case "filled":
+			s.filled = true
+		case "rounded":
+			s.rounded = true
+		case "dashed":
+			s.dashed = true
+		case "dotted":
+			s.dotted = true
+		case "bold":
+			s.bold = true
+		case "invis":
+			s.invisible = true
+		case "solid":
+		default:
+			translated["style"] = false
+		}
+	}
+
+	color := func(names ...string) string {
+		for _, name := range names {
+			if v, ok := attrs[name]; ok {
+				return fmt.Sprint(v)
+			}
+		}
+		return ""
+	}
+	s.fill = color("fillcolor")
+	if len(s.fill) == 0 && s.filled {
+		s.fill = color("color")
+	}
+	if len(s.fill) == 0 {
+		s.fill = color("bgcolor")
+	}
+	s.stroke = color("pencolor", "color")
+	s.font = color("fontcolor")
+	s.width, _ = numericValue(attrs["penwidth"])
+	return s
+}
+
+// shapeOf returns the shape of a node, ellipse when unset.
+func shapeOf(attrs map[string]interface{}) string {
+	if s, ok := attrs["shape"].(string); ok {
+		return s
+	}
+	return "ellipse"
+}
+
+// edgeDir returns the arrow direction of an edge: forward, back, both or none.
+func edgeDir(e *Edge, attrs map[string]interface{}) string {
+	dir := "forward"
+	if e.graph.Root().graphType == Undirected.Name {
+		dir = "none"
+	}
+	if d, ok := attrs["dir"].(string); ok {
+		dir = d
+	}
+	if attrs["arrowhead"] == "none" && dir == "forward" {
+		dir = "none"
+	}
+	return dir
+}
+
+// styleWords splits the comma separated `style` attribute.
+func styleWords(v interface{}) map[string]bool {
+	res := map[string]bool{}
+	s, _ := v.(string)
+	for _, each := range strings.Split(s, ",") {
+		if each = strings.TrimSpace(each); len(each) > 0 {
+			res[each] = true
+		}
+	}
+	return res
+}
+
+// plainLabel returns the text of a label attribute, with the dot escape
+// sequences for line breaks replaced by newlines. HTML labels are not plain.
+func plainLabel(v interface{}) (string, bool) {
+	var s string
+	switch x := v.(type) {
+	case nil:
+		return "", true
+	case HTML:
+		return "", false
+	case Literal:
+		s = strings.Trim(string(x), `"`)
+	default:
+		s = fmt.Sprint(x)
+	}
+	s = strings.NewReplacer(`\n`, "\n", `\l`, "\n", `\r`, "\n").Replace(s)
+	return strings.TrimRight(s, "\n"), true
+}
+
+func (e *Edge) element() string {
+	denote := "->"
+	if e.graph.Root().graphType == Undirected.Name {
+		denote = "--"
+	}
+	return "edge " + e.from.id + denote + e.to.id
+}
+
+// attributeType returns the type of an attribute value in the data
+// exchange formats: boolean, int, double or string.
+func attributeType(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "boolean"
+	case int, int32, int64, uint:
+		return "int"
+	case float32, float64:
+		return "double"
+	}
+	return "string"
+}
+
+// commonType returns the type able to hold values of all the given types:
+// double for int and double values, string when they do not agree.
+func commonType(types map[string]bool) string {
+	switch {
+	case len(types) == 1:
+		for t := range types {
+			return t
+		}
+	case len(types) == 2 && types["int"] && types["double"]:
+		return "double"
+	}
+	return "string"
+}
+
+// attributeText formats an attribute value, numbers in their shortest form.
+func attributeText(v interface{}) string {
+	switch x := v.(type) {
+	case float32:
+		return strconv.FormatFloat(float64(x), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// exportID replaces the characters not allowed in identifiers by most
+// diagram languages with underscores.
+func exportID(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') {
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+// containerIDs returns the identifiers of the subgraphs of the top graph in
+// the diagram languages, where nodes are identified as n<seq>: exportID of
+// their identifier, with a number appended when it is already taken.
+// Identifiers left unchanged by exportID are issued first.
+func containerIDs(top *Graph) map[*Graph]string {
+	issued := map[string]bool{}
+	for _, n := range top.allNodes() {
+		issued[fmt.Sprintf("n%d", n.seq)] = true
+	}
+	subs := []*Graph{}
+	top.walkGraphs(func(each *Graph) {
+		if each != top {
+			subs = append(subs, each)
+		}
+	})
+	ids := map[*Graph]string{}
+	for _, valid := range []bool{true, false} {
+		for _, each := range subs {
+			base := exportID(each.id)
+			if (base == each.id) != valid {
+				continue
+			}
+			id := base
+			for i := 2; issued[id]; i++ {
+				id = base + "_" + strconv.Itoa(i)
+			}
+			issued[id], ids[each] = true, id
+		}
+	}
+	return ids
+}
+
+// graphElement names the graph or subgraph in reports.
+func graphElement(g *Graph) string {
+	if g.parent == nil {
+		return "graph"
+	}
+	return "subgraph " + g.id
+}
+
+// sameRank reports rank groups, for languages that cannot express them.
+func (r *ExportReport) sameRank(g *Graph, groups int) {
+	for i := 0; i < groups; i++ {
+		r.Untranslated = append(r.Untranslated, UntranslatedAttribute{Element: graphElement(g), Name: "rank", Value: "same"})
+	}
+}
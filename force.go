@@ -0,0 +1,96 @@
+package dot
+
+import (
+	"math"
+	"math/rand"
+)
+
+// ForceLayout computes a force-directed layout of the whole graph in pure
+// Go, with the Fruchterman-Reingold algorithm, in the manner of the
+// Graphviz neato and fdp programs, and stores it in the same attributes as
+// LayeredLayout; edges are straight and clusters are not drawn.
+//
+// Adjacent nodes attract and all nodes repel each other, so that the
+// outlines of adjacent nodes end up about `K` inches apart (0.3 by default)
+// after `maxiter` iterations (300 by default). Nodes with a `pos`, in
+// points, start there; those pinned with a trailing ! or `pin=true` do not
+// move, and the coordinates are not translated; otherwise the connected
+// components are placed side by side. The other nodes start at random
+// positions from the seed, so the same seed gives the same layout.
+func (g *Graph) ForceLayout(seed int64) {
+	root := g.Root()
+	geo := newGeometry(root)
+	attrs := geo.st.attributes(graphSelectable(root))
+	k := 0.3 * pointsPerInch
+	if f, ok := numericValue(attrs["K"]); ok && f > 0 {
+		k = f * pointsPerInch
+	}
+	iterations := 300
+	if f, ok := numericValue(attrs["maxiter"]); ok && f >= 0 {
+		iterations = int(f)
+	}
+
+	n := len(geo.adj.nodes)
+	rng := rand.New(rand.NewSource(seed))
+	pos := geo.center
+	pinned := geo.pinned
+	radius := make([]float64, n)
+	side := (k + 54) * math.Sqrt(float64(n))
+	for i := range pos {
+		if p, pin, ok := initialPosition(geo.nodeAttrs[i]); ok {
+			pos[i], pinned[i] = p, pin
+			geo.keepOrigin = geo.keepOrigin || pin
+		} else {
+			pos[i] = point{rng.Float64() * side, rng.Float64() * side}
+		}
+		radius[i] = math.Hypot(geo.size[i].x, geo.size[i].y) / 2
+	}
+
+	// distance between the outlines of two nodes, along the unit vector from j to i
+	between := func(i, j int) (float64, float64, float64) {
+		dx, dy := pos[i].x-pos[j].x, pos[i].y-pos[j].y
+		d := math.Hypot(dx, dy)
+		if d == 0 {
+			dx, dy = rng.Float64()-0.5, rng.Float64()-0.5
+			d = math.Hypot(dx, dy)
+		}
+		return dx / d, dy / d, math.Max(d-radius[i]-radius[j], 1)
+	}
+	hot := side / 10
+	for iter := 0; iter < iterations; iter++ {
+		disp := make([]point, n)
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				ux, uy, d := between(i, j)
+				f := k * k / d
+				disp[i] = point{disp[i].x + ux*f, disp[i].y + uy*f}
+				disp[j] = point{disp[j].x - ux*f, disp[j].y - uy*f}
+			}
+		}
+		for e := range geo.adj.edges {
+			u, v := geo.adj.from[e], geo.adj.to[e]
+			if u == v {
+				continue
+			}
+			ux, uy, d := between(v, u)
+			f := d * d / k
+			disp[u] = point{disp[u].x + ux*f, disp[u].y + uy*f}
+			disp[v] = point{disp[v].x - ux*f, disp[v].y - uy*f}
+		}
+		temperature := hot * (1 - float64(iter)/float64(iterations))
+		for i := range pos {
+			length := math.Hypot(disp[i].x, disp[i].y)
+			if pinned[i] || length == 0 {
+				continue
+			}
+			step := math.Min(length, temperature) / length
+			pos[i] = point{pos[i].x + disp[i].x*step, pos[i].y + disp[i].y*step}
+		}
+	}
+
+	if !geo.keepOrigin {
+		geo.pack(geo.components(nil), 0, k)
+	}
+	geo.straightRoutes()
+	geo.write(root)
+}
@@ -0,0 +1,108 @@
+package dot
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestForceLayout(t *testing.T) {
+	g := NewGraph(Undirected)
+	nodes := []*Node{g.Node(), g.Node(), g.Node(), g.Node(), g.Node(), g.Node()}
+	for i := range nodes {
+		g.Edge(nodes[i], nodes[(i+1)%len(nodes)])
+	}
+	g.ForceLayout(7)
+
+	for i, a := range nodes {
+		for _, b := range nodes[i+1:] {
+			pa, pb := nodePos(t, a), nodePos(t, b)
+			if math.Abs(pa.x-pb.x) < 54 && math.Abs(pa.y-pb.y) < 36 {
+				t.Errorf("got [%v] overlapping [%v]", pa, pb)
+			}
+		}
+	}
+	// adjacent nodes are closer than opposite ones
+	adjacent := math.Hypot(nodePos(t, nodes[0]).x-nodePos(t, nodes[1]).x, nodePos(t, nodes[0]).y-nodePos(t, nodes[1]).y)
+	opposite := math.Hypot(nodePos(t, nodes[0]).x-nodePos(t, nodes[3]).x, nodePos(t, nodes[0]).y-nodePos(t, nodes[3]).y)
+	if adjacent >= opposite {
+		t.Errorf("got [%v] want less than [%v]", adjacent, opposite)
+	}
+	if bb := boundingBox(t, g); bb.llx != 0 || bb.lly != 0 {
+		t.Errorf("got [%v] want origin 0,0", bb)
+	}
+	if pos := g.FindEdges(*nodes[0], *nodes[1])[0].Value("pos"); pos == nil {
+		t.Errorf("got [%v] want an edge route", pos)
+	}
+}
+
+func TestForceLayoutDeterministic(t *testing.T) {
+	graphs := []*Graph{NewGraph(Undirected), NewGraph(Undirected)}
+	for _, g := range graphs {
+		nodes := []*Node{g.Node(), g.Node(), g.Node(), g.Node(), g.Node(), g.Node()}
+		for i := range nodes {
+			g.Edge(nodes[i], nodes[(i+1)%len(nodes)])
+		}
+		g.ForceLayout(42)
+	}
+	one, two := graphs[0], graphs[1]
+	if got, want := two.String(), one.String(); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	two.ForceLayout(43)
+	if two.String() == one.String() {
+		t.Errorf("got the same layout from another seed")
+	}
+}
+
+func TestForceLayoutPinned(t *testing.T) {
+	g := NewGraph(Undirected)
+	nodes := []*Node{g.Node(), g.Node(), g.Node(), g.Node(), g.Node(), g.Node()}
+	for i := range nodes {
+		g.Edge(nodes[i], nodes[(i+1)%len(nodes)])
+	}
+	nodes[0].Attr("pos", "-100,50!")
+	nodes[3].Attr("pos", "300,50")
+	nodes[3].Attr("pin", true)
+	g.ForceLayout(1)
+	if got, want := nodePos(t, nodes[0]), (point{-100, 50}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := nodePos(t, nodes[3]), (point{300, 50}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if bb := boundingBox(t, g); bb.llx > -127 {
+		t.Errorf("got [%v] want the pinned node inside", bb)
+	}
+}
+
+func TestForceLayoutComponents(t *testing.T) {
+	g := NewGraph(Undirected)
+	a, b, c, d := g.Node(), g.Node(), g.Node(), g.Node()
+	g.Edge(a, b)
+	g.Edge(c, d)
+	g.ForceLayout(1)
+	left := math.Max(nodePos(t, a).x, nodePos(t, b).x)
+	right := math.Min(nodePos(t, c).x, nodePos(t, d).x)
+	if right-left < 54 {
+		t.Errorf("got [%v] and [%v] want components side by side", left, right)
+	}
+}
+
+func TestForceLayoutTwice(t *testing.T) {
+	g := NewGraph(Undirected)
+	nodes := []*Node{g.Node(), g.Node(), g.Node(), g.Node(), g.Node(), g.Node()}
+	for i := range nodes {
+		g.Edge(nodes[i], nodes[(i+1)%len(nodes)])
+	}
+	nodes[0].Attr("pos", "-100,50!")
+	g.ForceLayout(1)
+	g.CircularLayout()
+	g.ForceLayout(2)
+	if got, want := nodes[0].Value("pos"), "-100,50!"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got := attrString(nodes[1].Value("pos")); strings.HasSuffix(got, "!") {
+		t.Errorf("got [%v] want an unpinned position", got)
+	}
+}
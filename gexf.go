@@ -0,0 +1,228 @@
+package dot
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const gexfNamespace = "http://gexf.net/1.3"
+
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	Xmlns   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Mode            string           `xml:"mode,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           gexfNodes        `xml:"nodes"`
+	Edges           gexfEdges        `xml:"edges"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID      string  `xml:"id,attr"`
+	Title   string  `xml:"title,attr"`
+	Type    string  `xml:"type,attr"`
+	Default *string `xml:"default"`
+}
+
+type gexfNodes struct {
+	Nodes []gexfNode `xml:"node"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues *gexfAttValues `xml:"attvalues"`
+	Nodes     *gexfNodes     `xml:"nodes"`
+}
+
+type gexfEdges struct {
+	Edges []gexfEdge `xml:"edge"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	Label     string         `xml:"label,attr,omitempty"`
+	Weight    string         `xml:"weight,attr,omitempty"`
+	AttValues *gexfAttValues `xml:"attvalues"`
+}
+
+type gexfAttValues struct {
+	Values []gexfAttValue `xml:"attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// gexfTypes maps attribute types to GEXF attribute types.
+var gexfTypes = map[string]string{"boolean": "boolean", "int": "integer", "double": "double", "string": "string"}
+
+// WriteGEXF writes the graph in GEXF format, as used by Gephi. Subgraphs
+// become nodes containing their nodes; the label of nodes and edges and the
+// numeric weight of edges use the GEXF fields, all the other attributes
+// become typed GEXF attributes. The node and edge global attributes of the
+// top-level graph are the defaults of their attributes. Node and subgraph
+// identifiers must be unique.
+func (g *Graph) WriteGEXF(w io.Writer) error {
+	x := &gexfWriter{
+		titles: map[string]map[string]map[string]bool{"node": {}, "edge": {}},
+		ids:    map[string]bool{},
+	}
+	g.walkGraphs(func(each *Graph) {
+		if each != g {
+			x.collect("node", each.attributes)
+		}
+		for _, n := range each.nodes {
+			x.collect("node", n.attributes)
+		}
+		for _, edges := range each.edgesFrom {
+			for _, e := range edges {
+				x.collect("edge", e.attributes)
+			}
+		}
+	})
+	x.collect("node", g.nodeAttrs.attributes)
+	x.collect("edge", g.edgeAttrs.attributes)
+
+	edgeType := "directed"
+	if g.Root().graphType == Undirected.Name {
+		edgeType = "undirected"
+	}
+	doc := gexfDocument{Xmlns: gexfNamespace, Version: "1.3", Graph: gexfGraph{DefaultEdgeType: edgeType, Mode: "static"}}
+	doc.Graph.Attributes = []gexfAttributes{
+		x.declarations("node", g.nodeAttrs.attributes),
+		x.declarations("edge", g.edgeAttrs.attributes),
+	}
+
+	nodes, err := x.nodes(g)
+	if err != nil {
+		return err
+	}
+	doc.Graph.Nodes = *nodes
+	for i, e := range g.allEdges() {
+		el := gexfEdge{ID: fmt.Sprintf("e%d", i), Source: e.from.id, Target: e.to.id}
+		if label, ok := e.Value("label").(string); ok {
+			el.Label = label
+		}
+		if weight, ok := numericValue(e.Value("weight")); ok {
+			el.Weight = attributeText(weight)
+		}
+		el.AttValues = x.values("edge", e.attributes)
+		doc.Graph.Edges.Edges = append(doc.Graph.Edges.Edges, el)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+type gexfWriter struct {
+	// types seen for each attribute title, by class
+	titles map[string]map[string]map[string]bool
+	ids    map[string]bool
+}
+
+// native reports whether the attribute is written as a GEXF field.
+func (x *gexfWriter) native(class, name string, v interface{}) bool {
+	if name == "label" {
+		_, ok := v.(string)
+		return ok
+	}
+	if class == "edge" && name == "weight" {
+		_, ok := numericValue(v)
+		return ok
+	}
+	return false
+}
+
+func (x *gexfWriter) collect(class string, attrs map[string]interface{}) {
+	for name, v := range attrs {
+		if x.native(class, name, v) {
+			continue
+		}
+		if x.titles[class][name] == nil {
+			x.titles[class][name] = map[string]bool{}
+		}
+		x.titles[class][name][attributeType(v)] = true
+	}
+}
+
+func (x *gexfWriter) declarations(class string, defaults map[string]interface{}) gexfAttributes {
+	names := []string{}
+	for name := range x.titles[class] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	res := gexfAttributes{Class: class, Attributes: []gexfAttribute{}}
+	for _, name := range names {
+		attr := gexfAttribute{ID: name, Title: name, Type: gexfTypes[commonType(x.titles[class][name])]}
+		if v, ok := defaults[name]; ok && !x.native(class, name, v) {
+			text := attributeText(v)
+			attr.Default = &text
+		}
+		res.Attributes = append(res.Attributes, attr)
+	}
+	return res
+}
+
+func (x *gexfWriter) values(class string, attrs map[string]interface{}) *gexfAttValues {
+	res := &gexfAttValues{}
+	for name, v := range attrs {
+		if !x.native(class, name, v) {
+			res.Values = append(res.Values, gexfAttValue{For: name, Value: attributeText(v)})
+		}
+	}
+	if len(res.Values) == 0 {
+		return nil
+	}
+	sort.Slice(res.Values, func(i, j int) bool { return res.Values[i].For < res.Values[j].For })
+	return res
+}
+
+// nodes returns the nodes of the graph followed by its subgraphs.
+func (x *gexfWriter) nodes(g *Graph) (*gexfNodes, error) {
+	res := &gexfNodes{}
+	for _, n := range nodesBySeq(g) {
+		if x.ids[n.id] {
+			return nil, fmt.Errorf("node identifier %q is not unique", n.id)
+		}
+		x.ids[n.id] = true
+		label, _ := n.Value("label").(string)
+		res.Nodes = append(res.Nodes, gexfNode{ID: n.id, Label: label, AttValues: x.values("node", n.attributes)})
+	}
+	for _, sub := range g.orderedSubgraphs() {
+		if x.ids[sub.id] {
+			return nil, fmt.Errorf("subgraph identifier %q is not unique", sub.id)
+		}
+		x.ids[sub.id] = true
+		nested, err := x.nodes(sub)
+		if err != nil {
+			return nil, err
+		}
+		label, _ := sub.Value("label").(string)
+		res.Nodes = append(res.Nodes, gexfNode{ID: sub.id, Label: label, AttValues: x.values("node", sub.attributes), Nodes: nested})
+	}
+	return res, nil
+}
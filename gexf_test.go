@@ -0,0 +1,69 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteGEXF(t *testing.T) {
+	g := NewGraph(Directed)
+	g.NodeBaseAttrs().Attr("shape", "box")
+	a := g.NodeWithID("a", WithLabel("A"))
+	sub := g.NewSubgraph()
+	b := sub.NodeWithID("b", func(a *AttributesMap) { a.Attr("size", 2) })
+	g.Edge(a, b, WithLabel("go"), func(a *AttributesMap) {
+		a.Attr("weight", 1.5)
+		a.Attr("color", "red")
+	})
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteGEXF(buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://gexf.net/1.3" version="1.3">
+  <graph defaultedgetype="directed" mode="static">
+    <attributes class="node">
+      <attribute id="shape" title="shape" type="string">
+        <default>box</default>
+      </attribute>
+      <attribute id="size" title="size" type="integer"></attribute>
+    </attributes>
+    <attributes class="edge">
+      <attribute id="color" title="color" type="string"></attribute>
+    </attributes>
+    <nodes>
+      <node id="a" label="A"></node>
+      <node id="cluster_2" label="cluster_2">
+        <nodes>
+          <node id="b" label="b">
+            <attvalues>
+              <attvalue for="size" value="2"></attvalue>
+            </attvalues>
+          </node>
+        </nodes>
+      </node>
+    </nodes>
+    <edges>
+      <edge id="e0" source="a" target="b" label="go" weight="1.5">
+        <attvalues>
+          <attvalue for="color" value="red"></attvalue>
+        </attvalues>
+      </edge>
+    </edges>
+  </graph>
+</gexf>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestWriteGEXFDuplicateID(t *testing.T) {
+	g := NewGraph(Directed)
+	g.NodeWithID("a")
+	g.NewSubgraph().NodeWithID("a")
+	if err := g.WriteGEXF(new(bytes.Buffer)); err == nil {
+		t.Error("expected error for duplicate identifier")
+	}
+}
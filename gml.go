@@ -0,0 +1,137 @@
+package dot
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WriteGML writes the graph in GML format, as read by NetworkX, yEd and
+// Gephi. Nodes are identified by their sequence number and keep their
+// identifier as `name`; their attributes and those of the edges, merged
+// over the global ones, become GML keys. Subgraphs become group nodes
+// (isGroup 1) and their members refer to them with gid.
+func (g *Graph) WriteGML(w io.Writer) error {
+	b := new(strings.Builder)
+	fmt.Fprintln(b, "graph [")
+	directed := 1
+	if g.Root().graphType == Undirected.Name {
+		directed = 0
+	}
+	fmt.Fprintf(b, "  directed %d\n", directed)
+	gmlAttributes(b, "  ", g.attributes, map[string]bool{"directed": true})
+
+	// group identifiers follow the node sequence numbers
+	groups := map[*Graph]int{}
+	next := g.Root().seq
+	g.walkGraphs(func(each *Graph) {
+		if each == g {
+			return
+		}
+		next++
+		groups[each] = next
+		fmt.Fprintln(b, "  node [")
+		fmt.Fprintf(b, "    id %d\n", next)
+		fmt.Fprintf(b, "    name %s\n", gmlString(each.id))
+		fmt.Fprintln(b, "    isGroup 1")
+		if gid, ok := groups[each.parent]; ok {
+			fmt.Fprintf(b, "    gid %d\n", gid)
+		}
+		gmlAttributes(b, "    ", each.attributes, map[string]bool{"id": true, "name": true, "isGroup": true, "gid": true})
+		fmt.Fprintln(b, "  ]")
+	})
+
+	for _, n := range g.allNodes() {
+		fmt.Fprintln(b, "  node [")
+		fmt.Fprintf(b, "    id %d\n", n.seq)
+		fmt.Fprintf(b, "    name %s\n", gmlString(n.id))
+		if gid, ok := groups[n.graph]; ok {
+			fmt.Fprintf(b, "    gid %d\n", gid)
+		}
+		attrs := effectiveAttributes(n.graph, n.attributes, func(g *Graph) AttributesMap { return g.nodeAttrs })
+		gmlAttributes(b, "    ", attrs, map[string]bool{"id": true, "name": true, "gid": true})
+		fmt.Fprintln(b, "  ]")
+	}
+
+	for _, e := range g.allEdges() {
+		fmt.Fprintln(b, "  edge [")
+		fmt.Fprintf(b, "    source %d\n", e.from.seq)
+		fmt.Fprintf(b, "    target %d\n", e.to.seq)
+		attrs := effectiveAttributes(e.graph, e.attributes, func(g *Graph) AttributesMap { return g.edgeAttrs })
+		gmlAttributes(b, "    ", attrs, map[string]bool{"source": true, "target": true})
+		fmt.Fprintln(b, "  ]")
+	}
+	fmt.Fprintln(b, "]")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// gmlAttributes writes the attributes sorted by name, skipping the reserved
+// ones. Names are reduced to the characters allowed in GML keys; a number
+// is appended to the keys taken by a valid name or a reserved one.
+func gmlAttributes(b *strings.Builder, indent string, attrs map[string]interface{}, reserved map[string]bool) {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		if !reserved[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	keys := map[string]string{}
+	used := map[string]bool{}
+	for name := range reserved {
+		used[name] = true
+	}
+	for _, name := range names {
+		if gmlKey(name) == name {
+			keys[name], used[name] = name, true
+		}
+	}
+	for _, name := range names {
+		key := gmlKey(name)
+		if _, ok := keys[name]; ok || len(key) == 0 {
+			continue
+		}
+		for i := 2; used[key]; i++ {
+			key = gmlKey(name) + strconv.Itoa(i)
+		}
+		keys[name], used[key] = key, true
+	}
+	for _, name := range names {
+		key, ok := keys[name]
+		if !ok {
+			continue
+		}
+		var value string
+		switch v := attrs[name].(type) {
+		case bool:
+			value = "0"
+			if v {
+				value = "1"
+			}
+		case int, int32, int64, uint, float32, float64:
+			value = attributeText(v)
+		default:
+			value = gmlString(attributeText(v))
+		}
+		fmt.Fprintf(b, "%s%s %s\n", indent, key, value)
+	}
+}
+
+// gmlKey removes the characters not allowed in GML keys.
+func gmlKey(name string) string {
+	return strings.TrimLeft(strings.Map(func(r rune) rune {
+		if r == '_' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') {
+			return r
+		}
+		return -1
+	}, name), "0123456789_")
+}
+
+// gmlString quotes a string, using character entities for quotes and ampersands.
+func gmlString(s string) string {
+	return `"` + strings.NewReplacer("&", "&amp;", `"`, "&quot;").Replace(s) + `"`
+}
@@ -0,0 +1,81 @@
+package dot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteGML(t *testing.T) {
+	g := NewGraph(Undirected)
+	g.EdgeBaseAttrs().Attr("color", "gray")
+	a := g.Node(WithLabel(`say "hi"`))
+	sub := g.NewSubgraph()
+	b := sub.Node(func(a *AttributesMap) {
+		a.Attr("width", 1.5)
+		a.Attr("fixedsize", true)
+	})
+	g.Edge(a, b, func(a *AttributesMap) { a.Attr("weight", 2) })
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteGML(buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `graph [
+  directed 0
+  node [
+    id 4
+    name "cluster_2"
+    isGroup 1
+    label "cluster_2"
+  ]
+  node [
+    id 1
+    name "n1"
+    label "say &quot;hi&quot;"
+  ]
+  node [
+    id 3
+    name "n3"
+    gid 4
+    fixedsize 1
+    label "n3"
+    width 1.5
+  ]
+  edge [
+    source 1
+    target 3
+    color "gray"
+    weight 2
+  ]
+]
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestGMLKey(t *testing.T) {
+	if got, want := gmlKey("1-label_loc"), "label_loc"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestWriteGMLKeyCollisions(t *testing.T) {
+	g := NewGraph(Directed)
+	g.Node(func(a *AttributesMap) {
+		a.Attr("a-b", 1)
+		a.Attr("a_b", 2)
+		a.Attr("a.b", 3)
+		a.Attr("i-d", 4)
+	})
+	buf := new(bytes.Buffer)
+	if err := g.WriteGML(buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"    a_b 2\n", "    ab 1\n", "    ab2 3\n", "    id2 4\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("got [%v] want [%v]", buf.String(), want)
+		}
+	}
+}
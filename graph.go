@@ -44,6 +44,16 @@ func (e *Edge) Attrs() *AttributesMap {
 	return &e.AttributesMap
 }
 
+// From returns the tail node of the edge.
+func (e *Edge) From() *Node {
+	return e.from
+}
+
+// To returns the head node of the edge.
+func (e *Edge) To() *Node {
+	return e.to
+}
+
 // GraphOption is a Graph configuration option.
 type GraphOption interface {
 	Apply(*Graph)
@@ -92,6 +102,11 @@ type Graph struct {
 	sameRank  map[string][]Node
 	//
 	nodeAttrs AttributesMap
+	edgeAttrs AttributesMap
+	// collapsed clusters, by identifier (root only)
+	collapsed map[string]*collapsedCluster
+	// stylesheet applied when writing (root only)
+	stylesheet *Stylesheet
 }
 
 // NewGraph return a new initialized Graph.
@@ -104,6 +119,7 @@ func NewGraph(options ...GraphOption) *Graph {
 		subgraphs:     map[string]*Graph{},
 		sameRank:      map[string][]Node{},
 		nodeAttrs:     AttributesMap{attributes: map[string]interface{}{}},
+		edgeAttrs:     AttributesMap{attributes: map[string]interface{}{}},
 	}
 	for _, each := range options {
 		each.Apply(graph)
@@ -131,6 +147,11 @@ func (g *Graph) NodeBaseAttrs() *AttributesMap {
 	return &g.nodeAttrs
 }
 
+// EdgeBaseAttrs returns the edge global attributes.
+func (g *Graph) EdgeBaseAttrs() *AttributesMap {
+	return &g.edgeAttrs
+}
+
 // Root returns the top-level graph if this was a subgraph.
 func (g *Graph) Root() *Graph {
 	if g.parent == nil {
@@ -275,92 +296,102 @@ func (g *Graph) Write(w io.Writer) {
 }
 
 // IndentedWrite write the graph to a writer using simple TAB indentation.
+// Attributes set by the stylesheet in use, if any, are written too.
 func (g *Graph) IndentedWrite(w *IndentWriter) {
-	fmt.Fprintf(w, "%s %s {", g.graphType, g.id)
-	w.NewLineIndentWhile(func() {
-		// graph attributes
-		if len(g.AttributesMap.attributes) > 0 {
-			appendSortedMap(g.AttributesMap.attributes, false, w)
-			w.NewLine()
-		}
+	g.walk(&dotWriter{w: w, top: g, st: newStyler(g.Root())})
+}
 
-		// node global attributes
-		if len(g.nodeAttrs.attributes) > 0 {
-			w.NewLine()
-			fmt.Fprint(w, "node")
-			appendSortedMap(g.nodeAttrs.attributes, true, w)
-			w.NewLine()
-		}
+// dotWriter writes the graph in dot notation.
+type dotWriter struct {
+	w   *IndentWriter
+	top *Graph
+	st  *styler
+}
 
-		// subgraphs
-		if len(g.subgraphs) > 0 {
-			keys := g.sortedSubgraphsKeys()
+func (d *dotWriter) enterGraph(g *Graph) {
+	w := d.w
+	if g != d.top {
+		w.NewLine()
+	}
+	fmt.Fprintf(w, "%s %s {", g.graphType, g.id)
+	w.NewLine()
+	w.Indent()
 
-			for _, key := range keys {
-				w.NewLine()
-				if each, ok := g.FindSubgraph(key); ok {
-					//each := g.subgraphs[key]
-					each.IndentedWrite(w)
-				}
-			}
-		}
+	// graph attributes
+	if attrs := d.st.attributes(graphSelectable(g)); len(attrs) > 0 {
+		appendSortedMap(attrs, false, w)
+		w.NewLine()
+	}
 
-		// nodes
-		if tot := len(g.nodes); tot > 0 {
-			w.NewLine()
+	// node global attributes
+	if len(g.nodeAttrs.attributes) > 0 {
+		w.NewLine()
+		fmt.Fprint(w, "node")
+		appendSortedMap(g.nodeAttrs.attributes, true, w)
+		w.NewLine()
+	}
 
-			nodeKeys := g.sortedNodesKeys()
+	// edge global attributes
+	if len(g.edgeAttrs.attributes) > 0 {
+		w.NewLine()
+		fmt.Fprint(w, "edge")
+		appendSortedMap(g.edgeAttrs.attributes, true, w)
+		w.NewLine()
+	}
+}
 
-			for i, key := range nodeKeys {
-				each := g.nodes[key]
-				fmt.Fprintf(w, "n%d", each.seq)
-				appendSortedMap(each.attributes, true, w)
-				fmt.Fprintf(w, ";")
-				if i < tot-1 {
-					w.NewLine()
-				}
-			}
+func (d *dotWriter) nodes(g *Graph, nodes []*Node) {
+	w := d.w
+	w.NewLine()
+	for i, each := range nodes {
+		fmt.Fprintf(w, "n%d", each.seq)
+		appendSortedMap(d.st.attributes(nodeSelectable(each)), true, w)
+		fmt.Fprintf(w, ";")
+		if i < len(nodes)-1 {
+			w.NewLine()
 		}
+	}
+}
 
-		// edges
-		if tot := len(g.edgesFrom); tot > 0 {
-			w.NewLine()
-			w.NewLine()
+func (d *dotWriter) edges(g *Graph, groups [][]*Edge) {
+	w := d.w
+	w.NewLine()
+	w.NewLine()
 
-			denoteEdge := "->"
-			if g.graphType == "graph" {
-				denoteEdge = "--"
-			}
+	denoteEdge := "->"
+	if g.graphType == "graph" {
+		denoteEdge = "--"
+	}
 
-			edgeKeys := g.sortedEdgesFromKeys()
-
-			for i, each := range edgeKeys {
-				all := g.edgesFrom[each]
-				for _, each := range all {
-					fmt.Fprintf(w, "n%d%sn%d", each.from.seq, denoteEdge, each.to.seq)
-					appendSortedMap(each.attributes, true, w)
-					fmt.Fprint(w, ";")
-					if i < tot-1 {
-						w.NewLine()
-					}
-				}
+	for i, all := range groups {
+		for _, each := range all {
+			fmt.Fprintf(w, "n%d%sn%d", each.from.seq, denoteEdge, each.to.seq)
+			appendSortedMap(d.st.attributes(edgeSelectable(each)), true, w)
+			fmt.Fprint(w, ";")
+			if i < len(groups)-1 {
+				w.NewLine()
 			}
 		}
+	}
+}
 
-		if tot := len(g.sameRank); tot > 0 {
-			w.NewLine()
-
-			for _, nodes := range g.sameRank {
-				str := ""
-				for _, n := range nodes {
-					str += fmt.Sprintf("n%d;", n.seq)
-				}
-				fmt.Fprintf(w, "{rank=same; %s};", str)
-				w.NewLine()
-			}
+func (d *dotWriter) sameRank(g *Graph, groups [][]*Node) {
+	w := d.w
+	w.NewLine()
+	for _, nodes := range groups {
+		str := ""
+		for _, n := range nodes {
+			str += fmt.Sprintf("n%d;", n.seq)
 		}
-	})
+		fmt.Fprintf(w, "{rank=same; %s};", str)
+		w.NewLine()
+	}
+}
 
+func (d *dotWriter) leaveGraph(g *Graph) {
+	w := d.w
+	w.BackIndent()
+	w.NewLine()
 	fmt.Fprintf(w, "}")
 	w.NewLine()
 }
@@ -405,6 +436,150 @@ func (g *Graph) FindNodeByLabel(label string) (found *Node) {
 	return
 }
 
+// Clone returns a deep copy of the graph with all its subgraphs, nodes,
+// edges and rank groups. Identifiers and sequence numbers are preserved,
+// so the copy renders exactly like the original. Collapsed subgraphs are
+// not copied, so they cannot be expanded in the copy.
+func (g *Graph) Clone() *Graph {
+	return g.cloneWhere(func(Node) bool { return true })
+}
+
+// cloneWhere copies the graph keeping only the nodes accepted by keep
+// and the edges (and rank group members) connecting kept nodes.
+func (g *Graph) cloneWhere(keep func(Node) bool) *Graph {
+	nodes := map[int]*Node{}
+	c := g.cloneTree(nil, keep, nodes)
+	c.seq = g.Root().seq
+	c.stylesheet = g.Root().stylesheet
+	g.cloneEdges(c, nodes)
+	return c
+}
+
+func (g *Graph) cloneTree(parent *Graph, keep func(Node) bool, nodes map[int]*Node) *Graph {
+	c := NewGraph(GraphTypeOption{g.graphType})
+	c.id = g.id
+	c.isCluster = g.isCluster
+	c.parent = parent
+	c.AttributesMap = g.AttributesMap.clone()
+	c.nodeAttrs = g.nodeAttrs.clone()
+	c.edgeAttrs = g.edgeAttrs.clone()
+
+	for id, n := range g.nodes {
+		if !keep(n) {
+			continue
+		}
+		cn := Node{id: n.id, seq: n.seq, graph: c, AttributesMap: n.AttributesMap.clone()}
+		c.nodes[id] = cn
+		nodes[cn.seq] = &cn
+	}
+
+	for id, sub := range g.subgraphs {
+		c.subgraphs[id] = sub.cloneTree(c, keep, nodes)
+	}
+	return c
+}
+
+func (g *Graph) cloneEdges(c *Graph, nodes map[int]*Node) {
+	for from, all := range g.edgesFrom {
+		for _, e := range all {
+			fromNode, ok := nodes[e.from.seq]
+			if !ok {
+				continue
+			}
+			toNode, ok := nodes[e.to.seq]
+			if !ok {
+				continue
+			}
+			c.edgesFrom[from] = append(c.edgesFrom[from], Edge{
+				from:          fromNode,
+				to:            toNode,
+				AttributesMap: e.AttributesMap.clone(),
+				graph:         c,
+			})
+		}
+	}
+
+	for group, members := range g.sameRank {
+		for _, n := range members {
+			if cn, ok := nodes[n.seq]; ok {
+				c.sameRank[group] = append(c.sameRank[group], *cn)
+			}
+		}
+	}
+
+	for id, sub := range g.subgraphs {
+		sub.cloneEdges(c.subgraphs[id], nodes)
+	}
+}
+
+// walkGraphs calls fn for the graph and then, depth first, for all its
+// subgraphs in identifier order.
+func (g *Graph) walkGraphs(fn func(*Graph)) {
+	fn(g)
+	for _, sub := range g.orderedSubgraphs() {
+		sub.walkGraphs(fn)
+	}
+}
+
+// orderedSubgraphs returns the direct subgraphs in natural identifier
+// order (cluster_2 before cluster_10).
+func (g *Graph) orderedSubgraphs() []*Graph {
+	subs := make([]*Graph, 0, len(g.subgraphs))
+	for _, sub := range g.subgraphs {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return naturalLess(subs[i].id, subs[j].id)
+	})
+	return subs
+}
+
+// allNodes returns the nodes of the graph and of all its subgraphs
+// ordered by sequence number.
+func (g *Graph) allNodes() []*Node {
+	all := []*Node{}
+	g.walkGraphs(func(each *Graph) {
+		for _, n := range each.nodes {
+			n := n
+			all = append(all, &n)
+		}
+	})
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].seq < all[j].seq
+	})
+	return all
+}
+
+// allEdges returns the edges of the graph and of all its subgraphs
+// ordered by the sequence numbers of their endpoints. Parallel edges
+// keep their creation order.
+func (g *Graph) allEdges() []*Edge {
+	all := []*Edge{}
+	g.walkGraphs(func(each *Graph) {
+		for _, edges := range each.edgesFrom {
+			for i := range edges {
+				all = append(all, &edges[i])
+			}
+		}
+	})
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].from.seq != all[j].from.seq {
+			return all[i].from.seq < all[j].from.seq
+		}
+		return all[i].to.seq < all[j].to.seq
+	})
+	return all
+}
+
+// naturalLess orders identifiers by length first, so that numeric
+// suffixes sort as numbers.
+func naturalLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
 func (g *Graph) beCluster() {
 	g.id = "cluster_" + g.id
 }
@@ -0,0 +1,419 @@
+package dot
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GraphML mapping:
+//   - the graph and its subgraphs become <graph> elements; a subgraph is
+//     written as a <node> containing a nested <graph>, like yEd group nodes;
+//   - node identifiers are used as GraphML identifiers, so they must be
+//     unique across the graph and its subgraphs;
+//   - every attribute name becomes a typed <key> for its domain (graph, node
+//     or edge): int, double, boolean or string. The node and edge global
+//     attributes of the top-level graph are the <default> of their keys,
+//     those of subgraphs are written on the nodes and edges they apply to;
+//   - HTML and Literal values get their own keys, with id suffix ".html" and
+//     ".literal", so they are restored with their type;
+//   - rank groups have no GraphML equivalent and are not written.
+
+const graphMLNamespace = "http://graphml.graphdrawing.org/xmlns"
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr,omitempty"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID      string  `xml:"id,attr"`
+	For     string  `xml:"for,attr"`
+	Name    string  `xml:"attr.name,attr,omitempty"`
+	Type    string  `xml:"attr.type,attr,omitempty"`
+	Default *string `xml:"default"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr,omitempty"`
+	EdgeDefault string        `xml:"edgedefault,attr,omitempty"`
+	Data        []graphMLData `xml:"data"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID    string        `xml:"id,attr"`
+	Data  []graphMLData `xml:"data"`
+	Graph *graphMLGraph `xml:"graph"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphMLKeys collects the keys used by a graph, by domain and attribute name.
+type graphMLKeys struct {
+	keys map[string]*graphMLKey
+	// types seen for each key
+	types map[string]map[string]bool
+}
+
+// WriteGraphML writes the graph with its subgraphs in GraphML format.
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	keys := &graphMLKeys{keys: map[string]*graphMLKey{}, types: map[string]map[string]bool{}}
+	ids := map[string]bool{}
+	g.walkGraphs(func(each *Graph) {
+		keys.collect("graph", each.AttributesMap)
+		for _, n := range each.nodes {
+			keys.collect("node", withSubgraphDefaults(each, n.AttributesMap, nodeDefaults))
+		}
+		for _, edges := range each.edgesFrom {
+			for _, e := range edges {
+				keys.collect("edge", withSubgraphDefaults(each, e.AttributesMap, edgeDefaults))
+			}
+		}
+	})
+	keys.collect("node", g.nodeAttrs)
+	keys.collect("edge", g.edgeAttrs)
+
+	doc := graphMLDocument{Xmlns: graphMLNamespace}
+	graph, err := g.toGraphML(keys, ids)
+	if err != nil {
+		return err
+	}
+	graph.ID = g.id
+	if len(graph.ID) == 0 {
+		graph.ID = "G"
+	}
+	doc.Graph = *graph
+	doc.Keys = keys.declarations(g)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func (g *Graph) toGraphML(keys *graphMLKeys, ids map[string]bool) (*graphMLGraph, error) {
+	edgeDefault := "directed"
+	if g.Root().graphType == Undirected.Name {
+		edgeDefault = "undirected"
+	}
+	graph := &graphMLGraph{
+		ID:          g.id + ":",
+		EdgeDefault: edgeDefault,
+		Data:        keys.data("graph", g.AttributesMap),
+	}
+
+	nodes := make([]Node, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].seq < nodes[j].seq })
+	for _, n := range nodes {
+		if ids[n.id] {
+			return nil, fmt.Errorf("node identifier %q is not unique", n.id)
+		}
+		ids[n.id] = true
+		graph.Nodes = append(graph.Nodes, graphMLNode{ID: n.id, Data: keys.data("node", withSubgraphDefaults(g, n.AttributesMap, nodeDefaults))})
+	}
+
+	for _, sub := range g.orderedSubgraphs() {
+		if ids[sub.id] {
+			return nil, fmt.Errorf("subgraph identifier %q is not unique", sub.id)
+		}
+		ids[sub.id] = true
+		nested, err := sub.toGraphML(keys, ids)
+		if err != nil {
+			return nil, err
+		}
+		graph.Nodes = append(graph.Nodes, graphMLNode{ID: sub.id, Graph: nested})
+	}
+
+	edges := []Edge{}
+	for _, all := range g.edgesFrom {
+		edges = append(edges, all...)
+	}
+	sort.SliceStable(edges, func(i, j int) bool {
+		if edges[i].from.seq != edges[j].from.seq {
+			return edges[i].from.seq < edges[j].from.seq
+		}
+		return edges[i].to.seq < edges[j].to.seq
+	})
+	for _, e := range edges {
+		graph.Edges = append(graph.Edges, graphMLEdge{
+			Source: e.from.id,
+			Target: e.to.id,
+			Data:   keys.data("edge", withSubgraphDefaults(g, e.AttributesMap, edgeDefaults)),
+		})
+	}
+	return graph, nil
+}
+
+func nodeDefaults(g *Graph) AttributesMap { return g.nodeAttrs }
+
+func edgeDefaults(g *Graph) AttributesMap { return g.edgeAttrs }
+
+// withSubgraphDefaults returns the attributes of a node or edge of the graph
+// merged over the global node or edge attributes of the subgraphs containing
+// it. Those of the top-level graph are left to the key defaults.
+func withSubgraphDefaults(g *Graph, a AttributesMap, global func(*Graph) AttributesMap) AttributesMap {
+	if g.parent == nil {
+		return a
+	}
+	res := AttributesMap{attributes: map[string]interface{}{}}
+	for ; g.parent != nil; g = g.parent {
+		for k, v := range global(g).attributes {
+			if _, ok := res.attributes[k]; !ok {
+				res.attributes[k] = v
+			}
+		}
+	}
+	for k, v := range a.attributes {
+		res.attributes[k] = v
+	}
+	return res
+}
+
+// graphMLKeyID returns the identifier of the key for the attribute value in the domain.
+func graphMLKeyID(domain, name string, value interface{}) string {
+	switch value.(type) {
+	case HTML:
+		return domain + "." + name + ".html"
+	case Literal:
+		return domain + "." + name + ".literal"
+	}
+	return domain + "." + name
+}
+
+func (k *graphMLKeys) collect(domain string, a AttributesMap) {
+	for name, v := range a.attributes {
+		id := graphMLKeyID(domain, name, v)
+		if _, ok := k.keys[id]; !ok {
+			k.keys[id] = &graphMLKey{ID: id, For: domain, Name: name}
+			k.types[id] = map[string]bool{}
+		}
+		k.types[id][attributeType(v)] = true
+	}
+}
+
+func (k *graphMLKeys) declarations(g *Graph) []graphMLKey {
+	defaults := map[string]string{}
+	for domain, a := range map[string]AttributesMap{"node": g.nodeAttrs, "edge": g.edgeAttrs} {
+		for name, v := range a.attributes {
+			defaults[graphMLKeyID(domain, name, v)] = attributeText(v)
+		}
+	}
+
+	res := []graphMLKey{}
+	for id, key := range k.keys {
+		key.Type = commonType(k.types[id])
+		if v, ok := defaults[id]; ok {
+			v := v
+			key.Default = &v
+		}
+		res = append(res, *key)
+	}
+	domains := map[string]int{"graph": 0, "node": 1, "edge": 2}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].For != res[j].For {
+			return domains[res[i].For] < domains[res[j].For]
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// data returns the data elements for the attributes, sorted by key.
+func (k *graphMLKeys) data(domain string, a AttributesMap) []graphMLData {
+	res := []graphMLData{}
+	for name, v := range a.attributes {
+		res = append(res, graphMLData{Key: graphMLKeyID(domain, name, v), Value: attributeText(v)})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Key < res[j].Key })
+	return res
+}
+
+// ReadGraphML reads a graph in GraphML format. Nested graphs become
+// subgraphs; the graph is Undirected if its edgedefault is undirected,
+// Directed otherwise. The graph takes the identifier of the <graph>
+// element, unless it is G, the one written for graphs without identifier.
+// Data without a declared attribute name, such as yEd graphics, is ignored.
+func ReadGraphML(r io.Reader) (*Graph, error) {
+	doc := graphMLDocument{}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]graphMLKey{}
+	for _, key := range doc.Keys {
+		if len(key.Name) > 0 {
+			keys[key.ID] = key
+		}
+	}
+
+	graphType := Directed
+	if doc.Graph.EdgeDefault == "undirected" {
+		graphType = Undirected
+	}
+	g := NewGraph(graphType)
+	if doc.Graph.ID != "G" {
+		g.id = doc.Graph.ID
+	}
+	for _, key := range keys {
+		if key.Default == nil {
+			continue
+		}
+		v, err := graphMLParse(key, *key.Default)
+		if err != nil {
+			return nil, err
+		}
+		switch key.For {
+		case "node":
+			g.nodeAttrs.Attr(key.Name, v)
+		case "edge":
+			g.edgeAttrs.Attr(key.Name, v)
+		}
+	}
+
+	nodes := map[string]*Node{}
+	subgraphs := map[string]*Graph{}
+	if err := g.readGraphMLNodes(&doc.Graph, keys, nodes, subgraphs); err != nil {
+		return nil, err
+	}
+	if err := g.readGraphMLEdges(&doc.Graph, keys, nodes, subgraphs); err != nil {
+		return nil, err
+	}
+	g.walkGraphs(func(each *Graph) {
+		if n := subgraphSeq(each.id); n > g.seq {
+			g.seq = n
+		}
+	})
+	return g, nil
+}
+
+func (g *Graph) readGraphMLNodes(graph *graphMLGraph, keys map[string]graphMLKey, nodes map[string]*Node, subgraphs map[string]*Graph) error {
+	if err := graphMLReadData(&g.AttributesMap, graph.Data, keys, "graph"); err != nil {
+		return err
+	}
+	for _, each := range graph.Nodes {
+		if _, dup := nodes[each.ID]; dup {
+			return fmt.Errorf("duplicate node %q", each.ID)
+		}
+		if _, dup := subgraphs[each.ID]; dup {
+			return fmt.Errorf("duplicate node %q", each.ID)
+		}
+
+		if each.Graph == nil {
+			n := g.NodeWithID(each.ID)
+			if err := graphMLReadData(n.Attrs(), each.Data, keys, "node"); err != nil {
+				return err
+			}
+			nodes[n.id] = n
+			continue
+		}
+
+		sub := g.NewSubgraph()
+		delete(g.subgraphs, sub.id)
+		sub.id = each.ID
+		sub.Attr("label", each.ID)
+		g.subgraphs[sub.id] = sub
+		subgraphs[sub.id] = sub
+		if err := graphMLReadData(&sub.AttributesMap, each.Data, keys, "node"); err != nil {
+			return err
+		}
+		if err := sub.readGraphMLNodes(each.Graph, keys, nodes, subgraphs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Graph) readGraphMLEdges(graph *graphMLGraph, keys map[string]graphMLKey, nodes map[string]*Node, subgraphs map[string]*Graph) error {
+	for _, each := range graph.Edges {
+		from, ok := nodes[each.Source]
+		if !ok {
+			return fmt.Errorf("edge from unknown node %q", each.Source)
+		}
+		to, ok := nodes[each.Target]
+		if !ok {
+			return fmt.Errorf("edge to unknown node %q", each.Target)
+		}
+		e := g.Edge(from, to)
+		if err := graphMLReadData(e.Attrs(), each.Data, keys, "edge"); err != nil {
+			return err
+		}
+	}
+	for _, each := range graph.Nodes {
+		if each.Graph == nil {
+			continue
+		}
+		if err := subgraphs[each.ID].readGraphMLEdges(each.Graph, keys, nodes, subgraphs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// graphMLReadData sets the attributes for the data elements. Data for keys
+// of another domain than the expected one is ignored, except keys for "all".
+func graphMLReadData(a *AttributesMap, data []graphMLData, keys map[string]graphMLKey, domain string) error {
+	for _, each := range data {
+		key, ok := keys[each.Key]
+		if !ok || (key.For != domain && key.For != "all") {
+			continue
+		}
+		v, err := graphMLParse(key, each.Value)
+		if err != nil {
+			return err
+		}
+		a.Attr(key.Name, v)
+	}
+	return nil
+}
+
+func graphMLParse(key graphMLKey, s string) (interface{}, error) {
+	switch {
+	case strings.HasSuffix(key.ID, ".html"):
+		return HTML(s), nil
+	case strings.HasSuffix(key.ID, ".literal"):
+		return Literal(s), nil
+	}
+	var (
+		v   interface{}
+		err error
+	)
+	switch key.Type {
+	case "boolean":
+		v, err = strconv.ParseBool(s)
+	case "int", "long":
+		v, err = strconv.Atoi(s)
+	case "float", "double":
+		v, err = strconv.ParseFloat(s, 64)
+	default:
+		v = s
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %q: invalid %s value %q", key.ID, key.Type, s)
+	}
+	return v, nil
+}
@@ -0,0 +1,186 @@
+package dot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteGraphML(t *testing.T) {
+	g := NewGraph(Undirected)
+	g.NodeBaseAttrs().Attr("shape", "box")
+	a := g.Node(WithLabel("a"))
+	b := g.Node(WithLabel("b"))
+	g.Edge(a, b, func(a *AttributesMap) { a.Attr("weight", 3) })
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteGraphML(buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="node.label" for="node" attr.name="label" attr.type="string"></key>
+  <key id="node.shape" for="node" attr.name="shape" attr.type="string">
+    <default>box</default>
+  </key>
+  <key id="edge.weight" for="edge" attr.name="weight" attr.type="int"></key>
+  <graph id="G" edgedefault="undirected">
+    <node id="n1">
+      <data key="node.label">a</data>
+    </node>
+    <node id="n2">
+      <data key="node.label">b</data>
+    </node>
+    <edge source="n1" target="n2">
+      <data key="edge.weight">3</data>
+    </edge>
+  </graph>
+</graphml>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestGraphMLRoundTrip(t *testing.T) {
+	g := NewGraph(Directed)
+	g.ID("flow")
+	g.Attr("rankdir", "LR")
+	g.NodeBaseAttrs().Attr("shape", "box")
+	g.EdgeBaseAttrs().Attr("color", "gray")
+	a := g.Node(WithLabel("a"))
+	sub := g.NewSubgraph()
+	sub.Attr("style", "filled")
+	b := sub.Node(func(a *AttributesMap) {
+		a.Attr("label", HTML("<b>b</b>"))
+		a.Attr("xlabel", Literal(`"x\l"`))
+		a.Attr("width", 1.5)
+		a.Attr("peripheries", 2)
+	})
+	c := sub.Node(WithLabel("c"))
+	g.Edge(a, b, WithLabel("ab"))
+	sub.Edge(b, c)
+	buf := new(bytes.Buffer)
+	if err := g.WriteGraphML(buf); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ReadGraphML(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := flatten(restored.String()), flatten(g.String()); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := restored.id, "flow"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	b = restored.FindNodeByID("n3")
+	if got, want := b.Value("label"), HTML("<b>b</b>"); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := b.Value("width"), 1.5; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := b.Value("peripheries"), 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestWriteGraphMLSubgraphDefaults(t *testing.T) {
+	g := NewGraph(Directed)
+	g.NodeBaseAttrs().Attr("color", "gray")
+	sub := g.NewSubgraph()
+	sub.NodeBaseAttrs().Attr("shape", "box")
+	sub.NodeBaseAttrs().Attr("color", "blue")
+	sub.EdgeBaseAttrs().Attr("style", "dashed")
+	nested := sub.NewSubgraph()
+	a := sub.NodeWithID("a")
+	nested.NodeWithID("b", func(a *AttributesMap) { a.Attr("shape", "circle") })
+	d := sub.NodeWithID("d")
+	sub.Edge(a, d)
+	c := g.NodeWithID("c")
+	g.Edge(c, a)
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteGraphML(buf); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ReadGraphML(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, each := range []struct {
+		id, shape, color string
+	}{
+		{"a", "box", "blue"},
+		{"b", "circle", "blue"},
+		{"d", "box", "blue"},
+	} {
+		n := restored.FindNodeByID(each.id)
+		if got, want := n.Value("shape"), each.shape; got != want {
+			t.Errorf("%s: got [%v] want [%v]", each.id, got, want)
+		}
+		if got, want := n.Value("color"), each.color; got != want {
+			t.Errorf("%s: got [%v] want [%v]", each.id, got, want)
+		}
+	}
+	if got, want := restored.FindNodeByID("c").Value("shape"), interface{}(nil); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := restored.nodeAttrs.Value("color"), "gray"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	restoredSub, ok := restored.FindSubgraph(sub.id)
+	if !ok {
+		t.Fatal("missing subgraph")
+	}
+	inside := restoredSub.FindEdges(*restored.FindNodeByID("a"), *restored.FindNodeByID("d"))
+	if got, want := inside[0].Value("style"), "dashed"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	outside := restored.FindEdges(*restored.FindNodeByID("c"), *restored.FindNodeByID("a"))
+	if got, want := outside[0].Value("style"), interface{}(nil); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestReadGraphMLNested(t *testing.T) {
+	src := `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns" xmlns:y="http://www.yworks.com/xml/graphml">
+  <key id="d0" for="node" attr.name="color" attr.type="string"/>
+  <key id="d1" for="node" yfiles.type="nodegraphics"/>
+  <graph id="G" edgedefault="directed">
+    <node id="a"><data key="d0">red</data><data key="d1"><y:ShapeNode/></data></node>
+    <node id="group">
+      <graph id="group:" edgedefault="directed">
+        <node id="b"/>
+      </graph>
+    </node>
+    <edge source="a" target="b"/>
+  </graph>
+</graphml>`
+	g, err := ReadGraphML(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.id, ""; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := g.FindNodeByID("a").Value("color"), "red"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	sub, ok := g.FindSubgraph("group")
+	if !ok {
+		t.Fatal("missing subgraph")
+	}
+	if got, want := len(sub.nodes), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(g.FindEdges(*g.FindNodeByID("a"), *g.FindNodeByID("b"))), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	if _, err := ReadGraphML(strings.NewReader(strings.Replace(src, `target="b"`, `target="c"`, 1))); err == nil {
+		t.Error("expected error for unknown node")
+	}
+}
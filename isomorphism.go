@@ -0,0 +1,339 @@
+package dot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CanonicalHash returns a hash of the graph structure that does not depend on
+// node identifiers, sequence numbers or creation order: isomorphic graphs have
+// the same hash. With attributes, graph, node and edge attributes must match
+// too. Subgraph membership is not considered.
+func (g *Graph) CanonicalHash(withAttributes bool) string {
+	_, cert := newCanonical(g, withAttributes).label()
+	sum := sha256.Sum256([]byte(cert))
+	return hex.EncodeToString(sum[:])
+}
+
+// Isomorphic reports whether the two graphs are structurally the same
+// (eventually including attributes, see CanonicalHash) and, if so, returns
+// a mapping from the node identifiers of a to the ones of b.
+func Isomorphic(a, b *Graph, withAttributes bool) (map[string]string, bool) {
+	ca, cb := newCanonical(a, withAttributes), newCanonical(b, withAttributes)
+	if len(ca.adj.nodes) != len(cb.adj.nodes) || len(ca.adj.edges) != len(cb.adj.edges) {
+		return nil, false
+	}
+	orderA, certA := ca.label()
+	orderB, certB := cb.label()
+	if certA != certB {
+		return nil, false
+	}
+	mapping := make(map[string]string, len(orderA))
+	for i, u := range orderA {
+		mapping[ca.adj.nodes[u].id] = cb.adj.nodes[orderB[i]].id
+	}
+	return mapping, true
+}
+
+// canonical computes a canonical labeling by color refinement followed by
+// an individualization search keeping the smallest certificate.
+type canonical struct {
+	adj        *adjacency
+	undirected bool
+	meta       string
+	nodeColor  []int
+	nodeSigs   []string
+	out        [][]arc
+	in         [][]arc
+
+	best      string
+	bestOrder []int
+}
+
+// arc is one end of an edge: the node at the other end and the edge color.
+type arc struct {
+	node  int
+	color int
+}
+
+func newCanonical(g *Graph, withAttributes bool) *canonical {
+	adj := newAdjacency(g)
+	c := &canonical{
+		adj:        adj,
+		undirected: g.graphType == Undirected.Name,
+		out:        make([][]arc, len(adj.nodes)),
+		in:         make([][]arc, len(adj.nodes)),
+	}
+	c.meta = fmt.Sprintf("%s|%d|%d", g.graphType, len(adj.nodes), len(adj.edges))
+
+	nodeSigs := make([]string, len(adj.nodes))
+	edgeSigs := make([]string, len(adj.edges))
+	if withAttributes {
+		c.meta += "|" + attributesSignature(g.AttributesMap, g.id) + "|" + attributesSignature(g.nodeAttrs, "")
+		for i, n := range adj.nodes {
+			nodeSigs[i] = attributesSignature(n.AttributesMap, n.id)
+		}
+		for i, e := range adj.edges {
+			edgeSigs[i] = attributesSignature(e.AttributesMap, "")
+		}
+	}
+	c.nodeColor, c.nodeSigs = rankStrings(nodeSigs)
+	edgeColor, _ := rankStrings(edgeSigs)
+
+	for e := range adj.edges {
+		u, v := adj.from[e], adj.to[e]
+		c.out[u] = append(c.out[u], arc{node: v, color: edgeColor[e]})
+		if c.undirected {
+			if u != v {
+				c.out[v] = append(c.out[v], arc{node: u, color: edgeColor[e]})
+			}
+			continue
+		}
+		c.in[v] = append(c.in[v], arc{node: u, color: edgeColor[e]})
+	}
+	return c
+}
+
+// label returns the canonical order of the nodes and the certificate.
+func (c *canonical) label() ([]int, string) {
+	c.best, c.bestOrder = "", nil
+	c.search(c.refine(c.nodeColor))
+	return c.bestOrder, c.best
+}
+
+func (c *canonical) search(colors []int) {
+	cell := c.firstNonSingletonCell(colors)
+	if cell == nil {
+		order := make([]int, len(colors))
+		for u, color := range colors {
+			order[color] = u
+		}
+		if cert := c.certificate(order); c.bestOrder == nil || cert < c.best {
+			c.best, c.bestOrder = cert, order
+		}
+		return
+	}
+
+	tried := []int{}
+	for _, v := range cell {
+		twin := false
+		for _, w := range tried {
+			if twin = c.twins(v, w); twin {
+				break
+			}
+		}
+		if twin {
+			continue
+		}
+		tried = append(tried, v)
+
+		sigs := make([][]int, len(colors))
+		for u, color := range colors {
+			mark := 1
+			if u == v {
+				mark = 0
+			}
+			sigs[u] = []int{color, mark}
+		}
+		c.search(c.refine(rankSignatures(sigs)))
+	}
+}
+
+// refine splits color classes by the colors of the neighbors until stable.
+func (c *canonical) refine(colors []int) []int {
+	for {
+		sigs := make([][]int, len(colors))
+		for u := range colors {
+			sig := []int{colors[u]}
+			sig = append(sig, c.arcColors(c.out[u], colors)...)
+			sig = append(sig, -1)
+			sig = append(sig, c.arcColors(c.in[u], colors)...)
+			sigs[u] = sig
+		}
+		refined := rankSignatures(sigs)
+		if countClasses(refined) == countClasses(colors) {
+			return refined
+		}
+		colors = refined
+	}
+}
+
+func (c *canonical) arcColors(arcs []arc, colors []int) []int {
+	pairs := make([][2]int, len(arcs))
+	for i, a := range arcs {
+		pairs[i] = [2]int{colors[a.node], a.color}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	res := make([]int, 0, 2*len(pairs))
+	for _, p := range pairs {
+		res = append(res, p[0], p[1])
+	}
+	return res
+}
+
+// firstNonSingletonCell returns the members of the smallest color shared by
+// more than one node, or nil if the coloring is discrete.
+func (c *canonical) firstNonSingletonCell(colors []int) []int {
+	members := map[int][]int{}
+	target := -1
+	for u, color := range colors {
+		members[color] = append(members[color], u)
+		if len(members[color]) > 1 && (target < 0 || color < target) {
+			target = color
+		}
+	}
+	if target < 0 {
+		return nil
+	}
+	return members[target]
+}
+
+// twins reports whether swapping u and w maps the graph onto itself.
+func (c *canonical) twins(u, w int) bool {
+	if c.nodeColor[u] != c.nodeColor[w] {
+		return false
+	}
+	swap := func(x int) int {
+		switch x {
+		case u:
+			return w
+		case w:
+			return u
+		}
+		return x
+	}
+	same := func(a, b []arc) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		count := map[arc]int{}
+		for _, each := range a {
+			count[arc{node: swap(each.node), color: each.color}]++
+		}
+		for _, each := range b {
+			count[each]--
+		}
+		for _, n := range count {
+			if n != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return same(c.out[u], c.out[w]) && same(c.in[u], c.in[w])
+}
+
+// certificate encodes the graph relabeled by the given order.
+func (c *canonical) certificate(order []int) string {
+	pos := make([]int, len(order))
+	for i, u := range order {
+		pos[u] = i
+	}
+	b := new(strings.Builder)
+	b.WriteString(c.meta)
+	for _, u := range order {
+		b.WriteString("|")
+		b.WriteString(strconv.Itoa(c.nodeColor[u]))
+	}
+	edges := []string{}
+	for u, arcs := range c.out {
+		for _, a := range arcs {
+			if c.undirected && pos[a.node] < pos[u] {
+				continue
+			}
+			edges = append(edges, fmt.Sprintf("%06d>%06d:%d", pos[u], pos[a.node], a.color))
+		}
+	}
+	sort.Strings(edges)
+	b.WriteString("|")
+	b.WriteString(strings.Join(edges, ","))
+	b.WriteString("|")
+	b.WriteString(strings.Join(c.nodeSigs, ","))
+	return b.String()
+}
+
+func countClasses(colors []int) int {
+	seen := map[int]bool{}
+	for _, color := range colors {
+		seen[color] = true
+	}
+	return len(seen)
+}
+
+// rankSignatures maps each signature to its rank among the distinct signatures.
+func rankSignatures(sigs [][]int) []int {
+	order := make([]int, len(sigs))
+	for i := range order {
+		order[i] = i
+	}
+	less := func(a, b []int) bool {
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if a[i] != b[i] {
+				return a[i] < b[i]
+			}
+		}
+		return len(a) < len(b)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return less(sigs[order[i]], sigs[order[j]])
+	})
+	ranks := make([]int, len(sigs))
+	rank := 0
+	for i, u := range order {
+		if i > 0 && less(sigs[order[i-1]], sigs[u]) {
+			rank++
+		}
+		ranks[u] = rank
+	}
+	return ranks
+}
+
+// rankStrings maps each string to its rank among the distinct sorted strings,
+// which are returned too.
+func rankStrings(values []string) ([]int, []string) {
+	distinct := []string{}
+	seen := map[string]bool{}
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			distinct = append(distinct, v)
+		}
+	}
+	sort.Strings(distinct)
+	rank := map[string]int{}
+	for i, v := range distinct {
+		rank[v] = i
+	}
+	ranks := make([]int, len(values))
+	for i, v := range values {
+		ranks[i] = rank[v]
+	}
+	return ranks, distinct
+}
+
+// attributesSignature encodes attributes, including their value types, in key order.
+// A label equal to the given identifier, the default one, is left out.
+func attributesSignature(a AttributesMap, id string) string {
+	keys := make([]string, 0, len(a.attributes))
+	for k, v := range a.attributes {
+		if k == "label" && len(id) > 0 && v == id {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	b := new(strings.Builder)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%q=%T:%q;", k, a.attributes[k], fmt.Sprint(a.attributes[k]))
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package dot
+
+import "testing"
+
+func TestCanonicalHashIgnoresNumbering(t *testing.T) {
+	a := NewGraph(Directed)
+	a1 := a.Node(WithLabel("x"))
+	a2 := a.Node(WithLabel("y"))
+	a3 := a.Node(WithLabel("z"))
+	a.Edge(a1, a2)
+	a.Edge(a2, a3)
+	a.Edge(a1, a3)
+
+	b := NewGraph(Directed)
+	b.NewSubgraph()
+	b3 := b.Node(WithLabel("z"))
+	b2 := b.Node(WithLabel("y"))
+	b1 := b.Node(WithLabel("x"))
+	b.Edge(b1, b3)
+	b.Edge(b2, b3)
+	b.Edge(b1, b2)
+
+	if got, want := b.CanonicalHash(true), a.CanonicalHash(true); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	mapping, ok := Isomorphic(a, b, true)
+	if !ok {
+		t.Fatal("graphs must be isomorphic")
+	}
+	if got, want := mapping[a1.ID()], b1.ID(); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	b3.Attr("color", "red")
+	if b.CanonicalHash(true) == a.CanonicalHash(true) {
+		t.Error("attributes must change the hash")
+	}
+	if b.CanonicalHash(false) != a.CanonicalHash(false) {
+		t.Error("attributes must be ignored")
+	}
+}
+
+func TestIsomorphicDirection(t *testing.T) {
+	chain := func(reverse bool, kind GraphTypeOption) *Graph {
+		g := NewGraph(kind)
+		n1, n2, n3 := g.Node(), g.Node(), g.Node()
+		g.Edge(n1, n2)
+		if reverse {
+			g.Edge(n2, n3)
+		} else {
+			g.Edge(n3, n2)
+		}
+		return g
+	}
+	if _, ok := Isomorphic(chain(false, Directed), chain(true, Directed), false); ok {
+		t.Error("a path and a collider are not isomorphic")
+	}
+	if _, ok := Isomorphic(chain(false, Undirected), chain(true, Undirected), false); !ok {
+		t.Error("undirected paths must be isomorphic")
+	}
+}
+
+func TestCanonicalHashSymmetricGraph(t *testing.T) {
+	complete := func(size int) *Graph {
+		g := NewGraph(Undirected)
+		nodes := []*Node{}
+		for i := 0; i < size; i++ {
+			nodes = append(nodes, g.Node())
+		}
+		for i := range nodes {
+			for j := i + 1; j < size; j++ {
+				g.Edge(nodes[i], nodes[j])
+			}
+		}
+		return g
+	}
+	if complete(12).CanonicalHash(false) != complete(12).CanonicalHash(false) {
+		t.Error("hash must be stable")
+	}
+	if complete(12).CanonicalHash(false) == complete(11).CanonicalHash(false) {
+		t.Error("different graphs must have different hashes")
+	}
+}
+
+func TestIsomorphicDefaultLabels(t *testing.T) {
+	a := NewGraph(Directed)
+	a1, a2 := a.Node(), a.Node()
+	a.Edge(a1, a2)
+
+	c := NewGraph(Directed)
+	c1, c2 := c.Node(), c.Node()
+	c.Edge(c2, c1)
+
+	if got, want := c.CanonicalHash(true), a.CanonicalHash(true); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	mapping, ok := Isomorphic(a, c, true)
+	if !ok {
+		t.Fatal("graphs must be isomorphic")
+	}
+	if got, want := mapping[a1.ID()], c2.ID(); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
@@ -0,0 +1,349 @@
+package dot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// jsonGraph is the JSON representation of a graph, see MarshalJSON.
+type jsonGraph struct {
+	Type           string                     `json:"type"`
+	ID             string                     `json:"id"`
+	Seq            int                        `json:"seq,omitempty"`
+	Attributes     map[string]json.RawMessage `json:"attributes,omitempty"`
+	NodeAttributes map[string]json.RawMessage `json:"nodeAttributes,omitempty"`
+	EdgeAttributes map[string]json.RawMessage `json:"edgeAttributes,omitempty"`
+	Nodes          []jsonNode                 `json:"nodes,omitempty"`
+	Edges          []jsonEdge                 `json:"edges,omitempty"`
+	SameRank       map[string][]int           `json:"sameRank,omitempty"`
+	Subgraphs      []*jsonGraph               `json:"subgraphs,omitempty"`
+}
+
+type jsonNode struct {
+	ID         string                     `json:"id"`
+	Seq        int                        `json:"seq"`
+	Attributes map[string]json.RawMessage `json:"attributes,omitempty"`
+}
+
+type jsonEdge struct {
+	From       int                        `json:"from"`
+	To         int                        `json:"to"`
+	Attributes map[string]json.RawMessage `json:"attributes,omitempty"`
+}
+
+type jsonValue struct {
+	HTML    *string `json:"html,omitempty"`
+	Literal *string `json:"literal,omitempty"`
+}
+
+// MarshalJSON encodes the graph, with all its subgraphs, nodes, edges and
+// rank groups, as JSON.
+//
+// A graph is an object with its "type" (digraph, graph, strict or subgraph),
+// "id", "attributes", "nodeAttributes", "edgeAttributes", "nodes", "edges",
+// "sameRank" and "subgraphs", which use the same object recursively. The root
+// object also has "seq", the last sequence number.
+//
+// Nodes have an "id", a "seq" and "attributes". Edges and rank groups refer
+// to nodes by sequence number, since node identifiers are not required to
+// be unique across subgraphs. Attribute values are strings, numbers or
+// booleans; HTML values are written as {"html": "..."} and Literal values
+// as {"literal": "..."}. Numbers without a fractional part are restored as
+// int, the others as float64. Values of any other type are written as their
+// string form. Collapsed clusters and the stylesheet in use are not part of
+// the JSON. For example, an undirected graph with an edge between two nodes:
+//
+//	{"type":"graph","id":"","seq":2,
+//	 "nodes":[{"id":"n1","seq":1,"attributes":{"label":"a"}},
+//	          {"id":"n2","seq":2,"attributes":{"label":"b"}}],
+//	 "edges":[{"from":1,"to":2}]}
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	jg, err := g.toJSON()
+	if err != nil {
+		return nil, err
+	}
+	if g.parent == nil {
+		jg.Seq = g.seq
+	}
+	return json.Marshal(jg)
+}
+
+// UnmarshalJSON restores a graph encoded by MarshalJSON, in the schema
+// described there, replacing the content of g. On error g is left
+// unchanged. Identifiers and sequence numbers are preserved, so the
+// restored graph renders exactly like the original.
+func (g *Graph) UnmarshalJSON(data []byte) error {
+	jg := &jsonGraph{}
+	if err := json.Unmarshal(data, jg); err != nil {
+		return err
+	}
+
+	old := *g
+	*g = *NewGraph()
+	nodes := map[int]*Node{}
+	err := jg.fill(g, nodes)
+	if err == nil {
+		err = jg.addEdges(g, nodes)
+	}
+	if err != nil {
+		*g = old
+		return err
+	}
+
+	g.seq = jg.Seq
+	for seq := range nodes {
+		if seq > g.seq {
+			g.seq = seq
+		}
+	}
+	g.walkGraphs(func(each *Graph) {
+		if n := subgraphSeq(each.id); n > g.seq {
+			g.seq = n
+		}
+	})
+	return nil
+}
+
+func (g *Graph) toJSON() (*jsonGraph, error) {
+	jg := &jsonGraph{Type: g.graphType, ID: g.id}
+	var err error
+	if jg.Attributes, err = attributesToJSON(g.AttributesMap); err != nil {
+		return nil, err
+	}
+	if jg.NodeAttributes, err = attributesToJSON(g.nodeAttrs); err != nil {
+		return nil, err
+	}
+	if jg.EdgeAttributes, err = attributesToJSON(g.edgeAttrs); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]Node, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].seq < nodes[j].seq })
+	for _, n := range nodes {
+		attrs, err := attributesToJSON(n.AttributesMap)
+		if err != nil {
+			return nil, err
+		}
+		jg.Nodes = append(jg.Nodes, jsonNode{ID: n.id, Seq: n.seq, Attributes: attrs})
+	}
+
+	from := make([]string, 0, len(g.edgesFrom))
+	for key := range g.edgesFrom {
+		from = append(from, key)
+	}
+	sort.Slice(from, func(i, j int) bool {
+		return g.edgesFrom[from[i]][0].from.seq < g.edgesFrom[from[j]][0].from.seq
+	})
+	for _, key := range from {
+		for _, e := range g.edgesFrom[key] {
+			attrs, err := attributesToJSON(e.AttributesMap)
+			if err != nil {
+				return nil, err
+			}
+			jg.Edges = append(jg.Edges, jsonEdge{From: e.from.seq, To: e.to.seq, Attributes: attrs})
+		}
+	}
+
+	if len(g.sameRank) > 0 {
+		jg.SameRank = map[string][]int{}
+		for group, members := range g.sameRank {
+			seqs := make([]int, len(members))
+			for i, n := range members {
+				seqs[i] = n.seq
+			}
+			jg.SameRank[group] = seqs
+		}
+	}
+
+	for _, sub := range g.orderedSubgraphs() {
+		js, err := sub.toJSON()
+		if err != nil {
+			return nil, err
+		}
+		jg.Subgraphs = append(jg.Subgraphs, js)
+	}
+	return jg, nil
+}
+
+// fill sets up the graph tree with its nodes, collecting them by sequence number.
+func (jg *jsonGraph) fill(g *Graph, nodes map[int]*Node) error {
+	switch jg.Type {
+	case Directed.Name, Undirected.Name, Strict.Name, Sub.Name:
+	default:
+		return fmt.Errorf("unknown graph type %q", jg.Type)
+	}
+	g.graphType = jg.Type
+	g.id = jg.ID
+
+	var err error
+	if g.AttributesMap, err = attributesFromJSON(jg.Attributes); err != nil {
+		return err
+	}
+	if g.nodeAttrs, err = attributesFromJSON(jg.NodeAttributes); err != nil {
+		return err
+	}
+	if g.edgeAttrs, err = attributesFromJSON(jg.EdgeAttributes); err != nil {
+		return err
+	}
+
+	for _, jn := range jg.Nodes {
+		if jn.Seq <= 0 {
+			return fmt.Errorf("node %q has invalid seq %d", jn.ID, jn.Seq)
+		}
+		if _, dup := nodes[jn.Seq]; dup {
+			return fmt.Errorf("duplicate node seq %d", jn.Seq)
+		}
+		if _, dup := g.nodes[jn.ID]; dup {
+			return fmt.Errorf("duplicate node %q in graph %q", jn.ID, jg.ID)
+		}
+		attrs, err := attributesFromJSON(jn.Attributes)
+		if err != nil {
+			return err
+		}
+		n := Node{id: jn.ID, seq: jn.Seq, graph: g, AttributesMap: attrs}
+		g.nodes[n.id] = n
+		nodes[n.seq] = &n
+	}
+
+	for _, js := range jg.Subgraphs {
+		if _, dup := g.subgraphs[js.ID]; dup {
+			return fmt.Errorf("duplicate subgraph %q", js.ID)
+		}
+		sub := NewGraph()
+		sub.parent = g
+		if err := js.fill(sub, nodes); err != nil {
+			return err
+		}
+		g.subgraphs[sub.id] = sub
+	}
+	return nil
+}
+
+// addEdges adds the edges and rank groups once all nodes are known.
+func (jg *jsonGraph) addEdges(g *Graph, nodes map[int]*Node) error {
+	for _, je := range jg.Edges {
+		from, ok := nodes[je.From]
+		if !ok {
+			return fmt.Errorf("edge from unknown node seq %d", je.From)
+		}
+		to, ok := nodes[je.To]
+		if !ok {
+			return fmt.Errorf("edge to unknown node seq %d", je.To)
+		}
+		attrs, err := attributesFromJSON(je.Attributes)
+		if err != nil {
+			return err
+		}
+		g.edgesFrom[from.id] = append(g.edgesFrom[from.id], Edge{from: from, to: to, graph: g, AttributesMap: attrs})
+	}
+
+	for group, seqs := range jg.SameRank {
+		for _, seq := range seqs {
+			n, ok := nodes[seq]
+			if !ok {
+				return fmt.Errorf("rank group %q has unknown node seq %d", group, seq)
+			}
+			g.sameRank[group] = append(g.sameRank[group], *n)
+		}
+	}
+
+	for _, js := range jg.Subgraphs {
+		if err := js.addEdges(g.subgraphs[js.ID], nodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func attributesToJSON(a AttributesMap) (map[string]json.RawMessage, error) {
+	if len(a.attributes) == 0 {
+		return nil, nil
+	}
+	res := make(map[string]json.RawMessage, len(a.attributes))
+	for k, v := range a.attributes {
+		var (
+			data []byte
+			err  error
+		)
+		switch x := v.(type) {
+		case HTML:
+			s := string(x)
+			data, err = json.Marshal(jsonValue{HTML: &s})
+		case Literal:
+			s := string(x)
+			data, err = json.Marshal(jsonValue{Literal: &s})
+		case string, bool:
+			data, err = json.Marshal(x)
+		default:
+			if f, ok := numericValue(x); ok {
+				if math.IsNaN(f) || math.IsInf(f, 0) {
+					return nil, fmt.Errorf("attribute %q: %v is not a valid JSON number", k, f)
+				}
+				data, err = json.Marshal(x)
+			} else {
+				data, err = json.Marshal(fmt.Sprint(x))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		res[k] = data
+	}
+	return res, nil
+}
+
+func attributesFromJSON(m map[string]json.RawMessage) (AttributesMap, error) {
+	a := AttributesMap{attributes: make(map[string]interface{}, len(m))}
+	for k, data := range m {
+		v, err := valueFromJSON(data)
+		if err != nil {
+			return a, fmt.Errorf("attribute %q: %v", k, err)
+		}
+		a.attributes[k] = v
+	}
+	return a, nil
+}
+
+func valueFromJSON(data json.RawMessage) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	switch x := v.(type) {
+	case string, bool:
+		return x, nil
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) <= 1<<53 {
+			return int(x), nil
+		}
+		return x, nil
+	case map[string]interface{}:
+		jv := jsonValue{}
+		if err := json.Unmarshal(data, &jv); err != nil {
+			return nil, err
+		}
+		if len(x) == 1 && jv.HTML != nil {
+			return HTML(*jv.HTML), nil
+		}
+		if len(x) == 1 && jv.Literal != nil {
+			return Literal(*jv.Literal), nil
+		}
+	}
+	return nil, errors.New("unsupported value " + string(data))
+}
+
+// subgraphSeq returns the sequence number encoded in a generated subgraph
+// identifier (cluster_<seq>), or 0.
+func subgraphSeq(id string) int {
+	n := 0
+	if _, err := fmt.Sscanf(id, "cluster_%d", &n); err != nil {
+		return 0
+	}
+	return n
+}
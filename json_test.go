@@ -0,0 +1,100 @@
+package dot
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	g := NewGraph(Directed)
+	g.Attr("rankdir", "LR")
+	g.NodeBaseAttrs().Attr("shape", "box")
+	g.EdgeBaseAttrs().Attr("color", "gray")
+	a := g.Node(WithLabel("a"))
+	sub := g.NewSubgraph()
+	sub.Attr("style", "filled")
+	b := sub.Node(func(a *AttributesMap) {
+		a.Attr("label", HTML("<b>b</b>"))
+		a.Attr("xlabel", Literal(`"x\l"`))
+		a.Attr("width", 1.5)
+		a.Attr("peripheries", 2)
+	})
+	c := sub.Node(WithLabel("c"))
+	g.Edge(a, b, WithLabel("ab"))
+	sub.Edge(b, c)
+	g.AddToSameRank("top", *a, *c)
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewGraph()
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := flatten(restored.String()), flatten(g.String()); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	b = restored.FindNodeByID("n3")
+	if got, want := b.Value("label"), HTML("<b>b</b>"); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := b.Value("xlabel"), Literal(`"x\l"`); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := b.Value("width"), 1.5; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := b.Value("peripheries"), 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	restoredSub, ok := restored.FindSubgraph("cluster_2")
+	if !ok {
+		t.Fatal("missing subgraph")
+	}
+	if got, want := restoredSub.Root(), restored; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := restored.NewSubgraph().id, "cluster_5"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	g := NewGraph(Undirected)
+	a := g.Node(WithLabel("a"))
+	b := g.Node(WithLabel("b"))
+	g.Edge(a, b)
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"graph","id":"","seq":2,` +
+		`"nodes":[{"id":"n1","seq":1,"attributes":{"label":"a"}},{"id":"n2","seq":2,"attributes":{"label":"b"}}],` +
+		`"edges":[{"from":1,"to":2}]}`
+	if got := string(data); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestJSONUnmarshalErrors(t *testing.T) {
+	for _, each := range []string{
+		`{"type":"tree"}`,
+		`{"type":"digraph","edges":[{"from":1,"to":2}]}`,
+		`{"type":"digraph","nodes":[{"id":"a","seq":1},{"id":"b","seq":1}]}`,
+		`{"type":"digraph","attributes":{"label":{"svg":"x"}}}`,
+	} {
+		g := NewGraph()
+		g.Node()
+		err := json.Unmarshal([]byte(each), g)
+		if err == nil {
+			t.Errorf("expected error for %s", each)
+			continue
+		}
+		if got, want := len(g.nodes), 1; got != want {
+			t.Errorf("got [%v] want [%v]", got, want)
+		}
+	}
+}
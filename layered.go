@@ -0,0 +1,784 @@
+package dot
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// LayeredLayout computes a hierarchical layout of the whole graph in pure
+// Go, in the manner of the Graphviz dot program, and stores it in the
+// attributes (see the layout attributes of Graphviz): `pos`, `width` and
+// `height` of the nodes, `pos` and `lp` of the edges, and `bb` and `lp` of
+// the graph and its clusters.
+//
+// Cycles are broken by reversing edges found by a depth first search, nodes
+// are assigned to layers by longest path, crossings are reduced by
+// barycenter ordering and nodes are placed close to the average position of
+// their neighbors. It honors `rankdir`, `nodesep` and `ranksep` of the
+// graph, the rank groups of AddToSameRank and subgraphs with rank=same, and
+// keeps the nodes of each cluster in a box of their own.
+func (g *Graph) LayeredLayout() {
+	root := g.Root()
+	l := newLayered(root)
+	l.rank()
+	l.expand()
+	l.order()
+	l.place()
+	l.output()
+	l.geo.write(root)
+}
+
+// layered holds the state of the hierarchical layout. The nodes of the
+// layout are the nodes of the graph (with the same index) followed by
+// virtual nodes: dummies along the edges spanning several layers and
+// fillers keeping the clusters contiguous across layers. Positions are
+// computed in layout space, u along the layers and v across them, and
+// turned into graph coordinates according to `rankdir`.
+type layered struct {
+	root    *Graph
+	geo     *geometry
+	dir     string
+	nodesep float64
+	ranksep float64
+
+	clusters     []*Graph
+	clusterPath  [][]int // enclosing clusters of each cluster, outermost first, itself included
+	clusterLabel []point
+
+	nodes  []*layeredNode
+	layers [][]int
+	// by edge index: the layout nodes from tail to head in layer order,
+	// nil for loops and edges within a layer; reversed edges go upward.
+	chains   [][]int
+	reversed []bool
+}
+
+type layeredNode struct {
+	clusters []int
+	layer    int
+	up, down []int
+	// size along and across the layers
+	extent, depth float64
+	u, v          float64
+	pos           int
+}
+
+func newLayered(root *Graph) *layered {
+	geo := newGeometry(root)
+	l := &layered{root: root, geo: geo}
+	attrs := geo.st.attributes(graphSelectable(root))
+	l.dir = strings.ToUpper(attrString(attrs["rankdir"]))
+	l.nodesep = 0.25 * pointsPerInch
+	if f, ok := numericValue(attrs["nodesep"]); ok && f >= 0 {
+		l.nodesep = f * pointsPerInch
+	}
+	l.ranksep = 0.5 * pointsPerInch
+	if f, ok := numericValue(firstWord(attrs["ranksep"])); ok && f >= 0 {
+		l.ranksep = f * pointsPerInch
+	}
+
+	l.clusters = geo.clusters
+	index := map[*Graph]int{}
+	for i, c := range l.clusters {
+		index[c] = i
+		w, h := labelSize(geo.st.attributes(graphSelectable(c)))
+		l.clusterLabel = append(l.clusterLabel, point{w, h})
+	}
+	path := func(g *Graph) []int {
+		res := []int{}
+		for ; g != nil; g = g.parent {
+			if i, ok := index[g]; ok {
+				res = append([]int{i}, res...)
+			}
+		}
+		return res
+	}
+	for _, c := range l.clusters {
+		l.clusterPath = append(l.clusterPath, path(c))
+	}
+	for i, n := range geo.adj.nodes {
+		extent, depth := geo.size[i].x, geo.size[i].y
+		if l.horizontal() {
+			extent, depth = depth, extent
+		}
+		l.nodes = append(l.nodes, &layeredNode{clusters: path(n.graph), extent: extent, depth: depth})
+	}
+	return l
+}
+
+// horizontal reports whether the layers are columns.
+func (l *layered) horizontal() bool {
+	return l.dir == "LR" || l.dir == "RL"
+}
+
+func firstWord(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		if fields := strings.Fields(s); len(fields) > 0 {
+			return fields[0]
+		}
+	}
+	return v
+}
+
+// rank assigns the nodes to layers. The nodes of a rank group are merged
+// into one, the cycles of the merged graph are broken by reversing the
+// edges that go back to a node on the depth first search stack, and each
+// node is placed one layer below its lowest predecessor; sources are then
+// moved down next to their highest successor.
+func (l *layered) rank() {
+	adj := l.geo.adj
+	n := len(adj.nodes)
+	group := make([]int, n)
+	for i := range group {
+		group[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if group[i] != i {
+			group[i] = find(group[i])
+		}
+		return group[i]
+	}
+	union := func(members []int) {
+		for _, each := range members {
+			a, b := find(members[0]), find(each)
+			if a > b {
+				a, b = b, a
+			}
+			group[b] = a
+		}
+	}
+	l.root.walkGraphs(func(each *Graph) {
+		for _, members := range each.sameRank {
+			indexes := []int{}
+			for _, m := range members {
+				if i, ok := adj.index[m.id]; ok {
+					indexes = append(indexes, i)
+				}
+			}
+			union(indexes)
+		}
+		if attrString(each.Value("rank")) == "same" {
+			indexes := []int{}
+			for id := range each.nodes {
+				indexes = append(indexes, adj.index[id])
+			}
+			if len(indexes) > 0 {
+				union(indexes)
+			}
+		}
+	})
+
+	out := make([][]int, n)
+	for e := range adj.edges {
+		if u, v := find(adj.from[e]), find(adj.to[e]); u != v {
+			out[u] = append(out[u], e)
+		}
+	}
+	l.reversed = make([]bool, len(adj.edges))
+	state := make([]int, n)
+	var visit func(int)
+	visit = func(u int) {
+		state[u] = 1
+		for _, e := range out[u] {
+			switch w := find(adj.to[e]); state[w] {
+			case 0:
+				visit(w)
+			case 1:
+				l.reversed[e] = true
+			}
+		}
+		state[u] = 2
+	}
+	for u := 0; u < n; u++ {
+		if find(u) == u && state[u] == 0 {
+			visit(u)
+		}
+	}
+
+	// longest path over the merged, acyclic graph
+	succ := make([][]int, n)
+	indegree := make([]int, n)
+	for u := range out {
+		for _, e := range out[u] {
+			t, h := u, find(adj.to[e])
+			if l.reversed[e] {
+				t, h = h, t
+			}
+			succ[t] = append(succ[t], h)
+			indegree[h]++
+		}
+	}
+	layer := make([]int, n)
+	queue := []int{}
+	for u := 0; u < n; u++ {
+		if find(u) == u && indegree[u] == 0 {
+			queue = append(queue, u)
+		}
+	}
+	sources := append([]int{}, queue...)
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, w := range succ[u] {
+			if layer[u]+1 > layer[w] {
+				layer[w] = layer[u] + 1
+			}
+			if indegree[w]--; indegree[w] == 0 {
+				queue = append(queue, w)
+			}
+		}
+	}
+	for _, u := range sources {
+		if len(succ[u]) > 0 {
+			highest := math.MaxInt32
+			for _, w := range succ[u] {
+				if layer[w] < highest {
+					highest = layer[w]
+				}
+			}
+			layer[u] = highest - 1
+		}
+	}
+	for i, each := range l.nodes {
+		each.layer = layer[find(i)]
+	}
+}
+
+// expand adds dummy nodes along the edges spanning several layers, fillers
+// in the layers of a cluster without nodes of it, and fills the layers.
+func (l *layered) expand() {
+	adj := l.geo.adj
+	l.chains = make([][]int, len(adj.edges))
+	for e := range adj.edges {
+		t, h := adj.from[e], adj.to[e]
+		if l.reversed[e] {
+			t, h = h, t
+		}
+		if l.nodes[t].layer == l.nodes[h].layer {
+			continue
+		}
+		common := commonPrefix(l.nodes[t].clusters, l.nodes[h].clusters)
+		chain := []int{t}
+		for layer := l.nodes[t].layer + 1; layer < l.nodes[h].layer; layer++ {
+			chain = append(chain, l.add(&layeredNode{clusters: common, layer: layer}))
+		}
+		chain = append(chain, h)
+		for i := 1; i < len(chain); i++ {
+			a, b := l.nodes[chain[i-1]], l.nodes[chain[i]]
+			a.down = append(a.down, chain[i])
+			b.up = append(b.up, chain[i-1])
+		}
+		l.chains[e] = chain
+	}
+
+	for c := range l.clusters {
+		present := map[int]bool{}
+		for _, each := range l.nodes {
+			if containsInt(each.clusters, c) {
+				present[each.layer] = true
+			}
+		}
+		first, last := l.clusterLayers(c)
+		for layer := first; layer <= last; layer++ {
+			if !present[layer] {
+				l.add(&layeredNode{clusters: l.clusterPath[c], layer: layer})
+			}
+		}
+	}
+
+	for i, each := range l.nodes {
+		for len(l.layers) <= each.layer {
+			l.layers = append(l.layers, []int{})
+		}
+		each.pos = len(l.layers[each.layer])
+		l.layers[each.layer] = append(l.layers[each.layer], i)
+	}
+}
+
+func (l *layered) add(n *layeredNode) int {
+	l.nodes = append(l.nodes, n)
+	return len(l.nodes) - 1
+}
+
+func commonPrefix(a, b []int) []int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
+
+func containsInt(all []int, x int) bool {
+	for _, each := range all {
+		if each == x {
+			return true
+		}
+	}
+	return false
+}
+
+// order reduces the edge crossings by sorting each layer by the barycenter
+// of the neighbors in the previous layer, sweeping down and then up, and
+// keeps the best ordering found. Clusters are sorted as a whole, so that
+// their nodes stay contiguous, and finally in the same order in all the
+// layers.
+func (l *layered) order() {
+	best := l.saveOrder()
+	fewest := l.crossings()
+	for iter := 0; iter < 24 && fewest > 0; iter++ {
+		if iter%2 == 0 {
+			for i := 1; i < len(l.layers); i++ {
+				l.sortLayer(l.layers[i], l.barycenter(func(n *layeredNode) []int { return n.up }), nil)
+			}
+		} else {
+			for i := len(l.layers) - 2; i >= 0; i-- {
+				l.sortLayer(l.layers[i], l.barycenter(func(n *layeredNode) []int { return n.down }), nil)
+			}
+		}
+		if c := l.crossings(); c < fewest {
+			fewest = c
+			best = l.saveOrder()
+		}
+	}
+	l.restoreOrder(best)
+
+	// a consistent order of the clusters keeps their boxes apart
+	sum := make([]float64, len(l.clusters))
+	count := make([]float64, len(l.clusters))
+	for _, each := range l.nodes {
+		for _, c := range each.clusters {
+			sum[c] += float64(each.pos) / float64(len(l.layers[each.layer]))
+			count[c]++
+		}
+	}
+	relative := func(v int) float64 {
+		n := l.nodes[v]
+		return float64(n.pos) / float64(len(l.layers[n.layer]))
+	}
+	for _, layer := range l.layers {
+		l.sortLayer(layer, relative, func(c int) float64 { return sum[c] / count[c] })
+	}
+}
+
+func (l *layered) saveOrder() [][]int {
+	res := make([][]int, len(l.layers))
+	for i, layer := range l.layers {
+		res[i] = append([]int{}, layer...)
+	}
+	return res
+}
+
+func (l *layered) restoreOrder(saved [][]int) {
+	for i, layer := range saved {
+		copy(l.layers[i], layer)
+		for pos, v := range layer {
+			l.nodes[v].pos = pos
+		}
+	}
+}
+
+// barycenter returns the average position of the given neighbors of a
+// node, its own position when it has none.
+func (l *layered) barycenter(neighbors func(*layeredNode) []int) func(int) float64 {
+	return func(v int) float64 {
+		n := l.nodes[v]
+		all := neighbors(n)
+		if len(all) == 0 {
+			return float64(n.pos)
+		}
+		sum := 0.0
+		for _, w := range all {
+			sum += float64(l.nodes[w].pos)
+		}
+		return sum / float64(len(all))
+	}
+}
+
+type orderItem struct {
+	key     float64
+	cluster int
+	nodes   []int
+}
+
+// sortLayer sorts the nodes of a layer by key. The nodes of a cluster are
+// sorted among themselves and placed together, at the average key of the
+// cluster or at clusterKey when given.
+func (l *layered) sortLayer(layer []int, key func(int) float64, clusterKey func(int) float64) {
+	sorted := l.sortGroup(layer, 0, key, clusterKey)
+	copy(layer, sorted)
+	for pos, v := range layer {
+		l.nodes[v].pos = pos
+	}
+}
+
+func (l *layered) sortGroup(nodes []int, depth int, key func(int) float64, clusterKey func(int) float64) []int {
+	items := []*orderItem{}
+	groups := map[int]*orderItem{}
+	for _, v := range nodes {
+		clusters := l.nodes[v].clusters
+		if len(clusters) <= depth {
+			items = append(items, &orderItem{key: key(v), cluster: -1, nodes: []int{v}})
+			continue
+		}
+		c := clusters[depth]
+		item, ok := groups[c]
+		if !ok {
+			item = &orderItem{cluster: c}
+			groups[c] = item
+			items = append(items, item)
+		}
+		item.nodes = append(item.nodes, v)
+	}
+	for _, item := range items {
+		if item.cluster < 0 {
+			continue
+		}
+		if clusterKey != nil {
+			item.key = clusterKey(item.cluster)
+		} else {
+			for _, v := range item.nodes {
+				item.key += key(v)
+			}
+			item.key /= float64(len(item.nodes))
+		}
+		item.nodes = l.sortGroup(item.nodes, depth+1, key, clusterKey)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].key < items[j].key })
+	res := make([]int, 0, len(nodes))
+	for _, item := range items {
+		res = append(res, item.nodes...)
+	}
+	return res
+}
+
+// crossings counts the crossings of the edges between adjacent layers.
+func (l *layered) crossings() int {
+	count := 0
+	for _, layer := range l.layers {
+		type segment struct{ a, b int }
+		segments := []segment{}
+		for _, v := range layer {
+			for _, w := range l.nodes[v].down {
+				segments = append(segments, segment{l.nodes[v].pos, l.nodes[w].pos})
+			}
+		}
+		for i := range segments {
+			for j := i + 1; j < len(segments); j++ {
+				s, t := segments[i], segments[j]
+				if (s.a < t.a && s.b > t.b) || (s.a > t.a && s.b < t.b) {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+// place computes the coordinates in layout space. Across the layers, the
+// layers are ranksep apart plus the margins and labels of the clusters
+// starting or ending between them. Along the layers, each node is moved
+// toward the average position of its neighbors in the adjacent layers, as
+// close as the separations allow, and then pushed right where needed to
+// keep the clusters out of each other's way.
+func (l *layered) place() {
+	depths := make([]float64, len(l.layers))
+	for _, each := range l.nodes {
+		depths[each.layer] = math.Max(depths[each.layer], each.depth)
+	}
+	extra := make([]float64, len(l.layers))
+	for c := range l.clusters {
+		first, last := l.clusterLayers(c)
+		if last < 0 {
+			continue
+		}
+		before, after := clusterMargin, clusterMargin
+		switch l.dir {
+		case "BT":
+			after += l.clusterLabel[c].y
+		case "LR", "RL":
+		default:
+			before += l.clusterLabel[c].y
+		}
+		if first > 0 {
+			extra[first-1] += before
+		}
+		extra[last] += after
+	}
+	v := 0.0
+	for i, layer := range l.layers {
+		if i > 0 {
+			v += depths[i-1]/2 + l.ranksep + extra[i-1] + depths[i]/2
+		}
+		for _, each := range layer {
+			l.nodes[each].v = v
+		}
+	}
+
+	for _, layer := range l.layers {
+		u := 0.0
+		for i, each := range layer {
+			if i > 0 {
+				u += l.separation(layer[i-1], each)
+			}
+			l.nodes[each].u = u
+		}
+	}
+	up := func(n *layeredNode) []int { return n.up }
+	down := func(n *layeredNode) []int { return n.down }
+	both := func(n *layeredNode) []int { return append(append([]int{}, n.up...), n.down...) }
+	for iter := 0; iter < 8; iter++ {
+		for i := 1; i < len(l.layers); i++ {
+			l.align(l.layers[i], up)
+		}
+		for i := len(l.layers) - 2; i >= 0; i-- {
+			l.align(l.layers[i], down)
+		}
+	}
+	for _, layer := range l.layers {
+		l.align(layer, both)
+	}
+	l.separateClusters()
+}
+
+// clusterLayers returns the first and last layer of a cluster.
+func (l *layered) clusterLayers(c int) (int, int) {
+	first, last := math.MaxInt32, -1
+	for _, each := range l.nodes {
+		if containsInt(each.clusters, c) {
+			if each.layer < first {
+				first = each.layer
+			}
+			if each.layer > last {
+				last = each.layer
+			}
+		}
+	}
+	return first, last
+}
+
+// separation returns the minimum distance between the centers of two
+// consecutive nodes of a layer.
+func (l *layered) separation(a, b int) float64 {
+	na, nb := l.nodes[a], l.nodes[b]
+	common := len(commonPrefix(na.clusters, nb.clusters))
+	res := na.extent/2 + l.nodesep + nb.extent/2
+	res += clusterMargin * float64(len(na.clusters)-common+len(nb.clusters)-common)
+	for _, c := range nb.clusters[common:] {
+		res += l.labelPad(c)
+	}
+	return res
+}
+
+// labelPad is the room for the label of a cluster before its first node,
+// needed along the layers when they are columns.
+func (l *layered) labelPad(c int) float64 {
+	if l.horizontal() {
+		return l.clusterLabel[c].y
+	}
+	return 0
+}
+
+// align moves the nodes of a layer as close as possible to the average
+// position of their neighbors, keeping their order and separation: a least
+// squares fit solved by pooling adjacent violators.
+func (l *layered) align(layer []int, neighbors func(*layeredNode) []int) {
+	type block struct {
+		sum, count float64
+		size       int
+	}
+	offset := 0.0
+	offsets := make([]float64, len(layer))
+	blocks := []block{}
+	for i, v := range layer {
+		n := l.nodes[v]
+		if i > 0 {
+			offset += l.separation(layer[i-1], v)
+		}
+		offsets[i] = offset
+		target := n.u
+		if all := neighbors(n); len(all) > 0 {
+			sum := 0.0
+			for _, w := range all {
+				sum += l.nodes[w].u
+			}
+			target = sum / float64(len(all))
+		}
+		blocks = append(blocks, block{target - offset, 1, 1})
+		for len(blocks) > 1 {
+			last, prev := blocks[len(blocks)-1], blocks[len(blocks)-2]
+			if prev.sum/prev.count <= last.sum/last.count {
+				break
+			}
+			blocks = append(blocks[:len(blocks)-2], block{prev.sum + last.sum, prev.count + last.count, prev.size + last.size})
+		}
+	}
+	i := 0
+	for _, b := range blocks {
+		for k := 0; k < b.size; k++ {
+			l.nodes[layer[i]].u = b.sum/b.count + offsets[i]
+			i++
+		}
+	}
+}
+
+// separateClusters pushes nodes right so that no node lies between the
+// left and right sides of a cluster it does not belong to, in any layer.
+// The sides are shared by all the layers, and every node is placed after
+// its left neighbor and the sides of the clusters between them.
+func (l *layered) separateClusters() {
+	if len(l.clusters) == 0 {
+		return
+	}
+	n := len(l.nodes)
+	left := func(c int) int { return n + 2*c }
+	right := func(c int) int { return n + 2*c + 1 }
+	type constraint struct {
+		to  int
+		gap float64
+	}
+	after := make([][]constraint, n+2*len(l.clusters))
+	indegree := make([]int, len(after))
+	link := func(from, to int, gap float64) {
+		after[from] = append(after[from], constraint{to, gap})
+		indegree[to]++
+	}
+	for _, layer := range l.layers {
+		// the last node or side placed, and the room it needs after it
+		prev, room := -1, 0.0
+		place := func(to int, before, after float64) {
+			if prev >= 0 {
+				link(prev, to, room+before)
+			}
+			prev, room = to, after
+		}
+		var path []int
+		for _, v := range append(append([]int{}, layer...), -1) {
+			var clusters []int
+			if v >= 0 {
+				clusters = l.nodes[v].clusters
+			}
+			common := len(commonPrefix(path, clusters))
+			for i := len(path) - 1; i >= common; i-- {
+				place(right(path[i]), clusterMargin, 0)
+			}
+			if v < 0 {
+				break
+			}
+			sep := l.nodesep
+			for _, c := range clusters[common:] {
+				place(left(c), sep, 0)
+				sep = clusterMargin + l.labelPad(c)
+			}
+			place(v, sep+l.nodes[v].extent/2, l.nodes[v].extent/2)
+			path = clusters
+		}
+	}
+
+	value := make([]float64, len(after))
+	for i := range value {
+		value[i] = math.Inf(-1)
+		if i < n {
+			value[i] = l.nodes[i].u
+		}
+	}
+	queue := []int{}
+	for i := range after {
+		if indegree[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+	done := 0
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		done++
+		for _, c := range after[i] {
+			value[c.to] = math.Max(value[c.to], value[i]+c.gap)
+			if indegree[c.to]--; indegree[c.to] == 0 {
+				queue = append(queue, c.to)
+			}
+		}
+	}
+	if done < len(after) {
+		return // the cluster order is not consistent, keep the aligned positions
+	}
+	for i := 0; i < n; i++ {
+		l.nodes[i].u = value[i]
+	}
+}
+
+// transform maps layout space to graph coordinates.
+func (l *layered) transform(u, v float64) point {
+	switch l.dir {
+	case "BT":
+		return point{u, v}
+	case "LR":
+		return point{v, -u}
+	case "RL":
+		return point{-v, -u}
+	}
+	return point{u, -v}
+}
+
+// output fills the geometry: node centers, edge routes along their dummy
+// nodes and cluster boxes around their nodes.
+func (l *layered) output() {
+	geo := l.geo
+	for i := range geo.adj.nodes {
+		geo.center[i] = l.transform(l.nodes[i].u, l.nodes[i].v)
+	}
+	for e, chain := range l.chains {
+		u, v := geo.adj.from[e], geo.adj.to[e]
+		switch {
+		case u == v:
+		case chain == nil:
+			geo.routes[e] = []point{geo.center[u], geo.center[v]}
+		default:
+			route := make([]point, len(chain))
+			for i, each := range chain {
+				route[i] = l.transform(l.nodes[each].u, l.nodes[each].v)
+			}
+			if l.reversed[e] {
+				for i, j := 0, len(route)-1; i < j; i, j = i+1, j-1 {
+					route[i], route[j] = route[j], route[i]
+				}
+			}
+			geo.routes[e] = route
+		}
+	}
+
+	// boxes in layout space, innermost clusters first
+	boxes := make([]box, len(l.clusters))
+	for i := range boxes {
+		boxes[i] = emptyBox()
+	}
+	for _, each := range l.nodes {
+		if len(each.clusters) > 0 {
+			c := each.clusters[len(each.clusters)-1]
+			boxes[c].addBox(centered(point{each.u, each.v}, each.extent, each.depth))
+		}
+	}
+	for c := len(l.clusters) - 1; c >= 0; c-- {
+		b := boxes[c]
+		if b.empty() {
+			continue
+		}
+		b = box{b.llx - clusterMargin - l.labelPad(c), b.lly - clusterMargin, b.urx + clusterMargin, b.ury + clusterMargin}
+		switch l.dir {
+		case "BT":
+			b.ury += l.clusterLabel[c].y
+		case "LR", "RL":
+		default:
+			b.lly -= l.clusterLabel[c].y
+		}
+		if path := l.clusterPath[c]; len(path) > 1 {
+			boxes[path[len(path)-2]].addBox(b)
+		}
+		res := emptyBox()
+		res.add(l.transform(b.llx, b.lly))
+		res.add(l.transform(b.urx, b.ury))
+		geo.boxes[c] = res
+	}
+}
@@ -0,0 +1,201 @@
+package dot
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// nodePos returns the position written by a layout.
+func nodePos(t *testing.T, n *Node) point {
+	t.Helper()
+	pos, ok := parseFloats(n.Value("pos"))
+	if !ok || len(pos) != 2 {
+		t.Fatalf("node %s: got pos [%v]", n.id, n.Value("pos"))
+	}
+	return point{pos[0], pos[1]}
+}
+
+func boundingBox(t *testing.T, g *Graph) box {
+	t.Helper()
+	bb, ok := parseFloats(g.Value("bb"))
+	if !ok || len(bb) != 4 {
+		t.Fatalf("%s: got bb [%v]", g.id, g.Value("bb"))
+	}
+	return box{bb[0], bb[1], bb[2], bb[3]}
+}
+
+func TestLayeredLayout(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b, c := g.Node(), g.Node(), g.Node()
+	g.Edge(a, b)
+	g.Edge(b, c)
+	g.Edge(a, c, WithLabel("skip"))
+	g.LayeredLayout()
+
+	pa, pb, pc := nodePos(t, a), nodePos(t, b), nodePos(t, c)
+	if !(pa.y > pb.y && pb.y > pc.y) {
+		t.Errorf("got [%v %v %v] want top to bottom", pa, pb, pc)
+	}
+	if got, want := pb.y-pc.y, 36+36.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := a.Value("width"), "0.75"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	bb := boundingBox(t, g)
+	if bb.llx != 0 || bb.lly != 0 {
+		t.Errorf("got [%v] want origin 0,0", bb)
+	}
+	for _, n := range []*Node{a, b, c} {
+		p := nodePos(t, n)
+		if p.x < bb.llx || p.x > bb.urx || p.y < bb.lly || p.y > bb.ury {
+			t.Errorf("got [%v] outside [%v]", p, bb)
+		}
+	}
+
+	edges := g.FindEdges(*a, *c)
+	pos := attrString(edges[0].Value("pos"))
+	if !strings.HasPrefix(pos, "e,") {
+		t.Errorf("got [%v] want an arrowhead", pos)
+	}
+	if got := len(strings.Fields(pos)) - 1; got%3 != 1 {
+		t.Errorf("got [%v] points want 3n+1", got)
+	}
+	if edges[0].Value("lp") == nil {
+		t.Errorf("got [%v] want a label position", nil)
+	}
+}
+
+func TestLayeredLayoutUndirected(t *testing.T) {
+	g := NewGraph(Undirected)
+	a, b := g.Node(), g.Node()
+	g.Edge(a, b)
+	g.LayeredLayout()
+	if pos := attrString(g.FindEdges(*a, *b)[0].Value("pos")); strings.Contains(pos, "e,") {
+		t.Errorf("got [%v] want no arrowhead", pos)
+	}
+}
+
+func TestLayeredLayoutRankdir(t *testing.T) {
+	for _, each := range []struct {
+		dir  string
+		want func(a, b point) bool
+	}{
+		{"TB", func(a, b point) bool { return a.y > b.y && a.x == b.x }},
+		{"BT", func(a, b point) bool { return a.y < b.y && a.x == b.x }},
+		{"LR", func(a, b point) bool { return a.x < b.x && a.y == b.y }},
+		{"RL", func(a, b point) bool { return a.x > b.x && a.y == b.y }},
+	} {
+		g := NewGraph(Directed)
+		g.Attr("rankdir", each.dir)
+		a, b := g.Node(), g.Node()
+		g.Edge(a, b)
+		g.LayeredLayout()
+		if pa, pb := nodePos(t, a), nodePos(t, b); !each.want(pa, pb) {
+			t.Errorf("%s: got [%v %v]", each.dir, pa, pb)
+		}
+	}
+}
+
+func TestLayeredLayoutSeparation(t *testing.T) {
+	g := NewGraph(Directed)
+	g.Attr("nodesep", "1")
+	g.Attr("ranksep", "2")
+	a, b, c := g.Node(), g.Node(), g.Node()
+	g.Edge(a, b)
+	g.Edge(a, c)
+	g.LayeredLayout()
+
+	pa, pb, pc := nodePos(t, a), nodePos(t, b), nodePos(t, c)
+	if got, want := pa.y-pb.y, 36+144.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := math.Abs(pb.x-pc.x), 54+72.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := pa.x, (pb.x+pc.x)/2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestLayeredLayoutSameRank(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b, c, d := g.Node(), g.Node(), g.Node(), g.Node()
+	g.Edge(a, b)
+	g.Edge(b, c)
+	g.Edge(a, d)
+	g.AddToSameRank("last", *c, *d)
+	g.LayeredLayout()
+	if got, want := nodePos(t, d).y, nodePos(t, c).y; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestLayeredLayoutCycle(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b, c := g.Node(), g.Node(), g.Node()
+	g.Edge(a, b)
+	g.Edge(b, c)
+	g.Edge(c, a)
+	g.Edge(c, c)
+	g.LayeredLayout()
+
+	pa, pb, pc := nodePos(t, a), nodePos(t, b), nodePos(t, c)
+	if !(pa.y > pb.y && pb.y > pc.y) {
+		t.Errorf("got [%v %v %v] want top to bottom", pa, pb, pc)
+	}
+	for _, e := range g.allEdges() {
+		if e.Value("pos") == nil {
+			t.Errorf("edge %s: got no pos", e.element())
+		}
+	}
+	// the arrow of the reversed edge points to its head
+	s, _ := parseFloats(strings.TrimPrefix(strings.Fields(attrString(g.FindEdges(*c, *a)[0].Value("pos")))[0], "e,"))
+	if got, want := s[1], pa.y-18; math.Abs(got-want) > 1 {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestLayeredLayoutClusters(t *testing.T) {
+	g := NewGraph(Directed)
+	outer := g.NewSubgraph()
+	inner := outer.NewSubgraph()
+	other := g.NewSubgraph()
+	a, b, c := g.Node(), outer.Node(), inner.Node()
+	d, e := inner.Node(), other.Node()
+	g.Edge(a, b)
+	g.Edge(a, e)
+	g.Edge(b, c)
+	g.Edge(c, d)
+	g.Edge(a, d)
+	g.Edge(e, g.Node())
+	g.LayeredLayout()
+
+	within := func(p point, b box) bool {
+		return p.x > b.llx && p.x < b.urx && p.y > b.lly && p.y < b.ury
+	}
+	bo, bi, bx := boundingBox(t, outer), boundingBox(t, inner), boundingBox(t, other)
+	for _, n := range []*Node{b, c, d} {
+		if !within(nodePos(t, n), bo) {
+			t.Errorf("got [%v] outside [%v]", nodePos(t, n), bo)
+		}
+	}
+	for _, n := range []*Node{c, d} {
+		if !within(nodePos(t, n), bi) {
+			t.Errorf("got [%v] outside [%v]", nodePos(t, n), bi)
+		}
+	}
+	if within(nodePos(t, b), bi) || within(nodePos(t, a), bo) || within(nodePos(t, e), bo) {
+		t.Errorf("got a node inside a cluster it does not belong to")
+	}
+	if bi.llx < bo.llx || bi.urx > bo.urx || bi.lly < bo.lly || bi.ury > bo.ury {
+		t.Errorf("got [%v] not inside [%v]", bi, bo)
+	}
+	if bo.llx < bx.urx && bx.llx < bo.urx && bo.lly < bx.ury && bx.lly < bo.ury {
+		t.Errorf("got [%v] overlapping [%v]", bo, bx)
+	}
+	if lp, ok := parseFloats(outer.Value("lp")); !ok || !within(point{lp[0], lp[1]}, bo) {
+		t.Errorf("got [%v] want a label position inside [%v]", outer.Value("lp"), bo)
+	}
+}
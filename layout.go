@@ -0,0 +1,609 @@
+package dot
+
+import (
+	"html"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The layout engines store their result in the attributes used by the
+// Graphviz output formats: coordinates are in points with the y axis going
+// up, the `pos` of a node is its center, `width` and `height` are in
+// inches, the `pos` of an edge is a B-spline and `bb` is the bounding box
+// "llx,lly,urx,ury" of the graph or cluster.
+
+const (
+	// pointsPerInch converts the node sizes.
+	pointsPerInch = 72.0
+	// arrowLength is the distance between an edge end and its arrow tip.
+	arrowLength = 10.0
+	// clusterMargin is the space between a cluster box and its content.
+	clusterMargin = 8.0
+)
+
+type point struct{ x, y float64 }
+
+// box is a rectangle, empty when its lower left corner is not below and to
+// the left of its upper right corner.
+type box struct{ llx, lly, urx, ury float64 }
+
+func emptyBox() box {
+	return box{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+}
+
+func (b box) empty() bool {
+	return b.llx > b.urx || b.lly > b.ury
+}
+
+// add grows the box to include the point.
+func (b *box) add(p point) {
+	b.llx = math.Min(b.llx, p.x)
+	b.lly = math.Min(b.lly, p.y)
+	b.urx = math.Max(b.urx, p.x)
+	b.ury = math.Max(b.ury, p.y)
+}
+
+// addBox grows the box to include another one.
+func (b *box) addBox(o box) {
+	if !o.empty() {
+		b.add(point{o.llx, o.lly})
+		b.add(point{o.urx, o.ury})
+	}
+}
+
+// centered returns the box of the given size around a point.
+func centered(c point, w, h float64) box {
+	return box{c.x - w/2, c.y - h/2, c.x + w/2, c.y + h/2}
+}
+
+func (b box) String() string {
+	return formatCoord(b.llx) + "," + formatCoord(b.lly) + "," + formatCoord(b.urx) + "," + formatCoord(b.ury)
+}
+
+// formatCoord formats a coordinate rounded to two decimals.
+func formatCoord(f float64) string {
+	f = math.Round(f*100) / 100
+	if f == 0 {
+		f = 0 // no negative zero
+	}
+	return formatFloat(f)
+}
+
+func formatPoint(p point) string {
+	return formatCoord(p.x) + "," + formatCoord(p.y)
+}
+
+// fontOf returns the font size and name of an element, 14 points
+// Times-Roman when unset.
+func fontOf(attrs map[string]interface{}) (float64, string) {
+	size, ok := numericValue(attrs["fontsize"])
+	if !ok || size <= 0 {
+		size = 14
+	}
+	name := attrString(attrs["fontname"])
+	if len(name) == 0 {
+		name = "Times-Roman"
+	}
+	return size, name
+}
+
+// textWidth estimates the width in points of a line of text, from the
+// average glyph widths of the standard PostScript fonts.
+func textWidth(text string, fontsize float64, fontname string) float64 {
+	font := strings.ToLower(fontname)
+	if strings.Contains(font, "courier") || strings.Contains(font, "mono") {
+		return float64(utf8.RuneCountInString(text)) * 0.6 * fontsize
+	}
+	w := 0.0
+	for _, r := range text {
+		switch {
+		case r == ' ':
+			w += 0.25
+		case strings.ContainsRune("fijlrtI.,:;'!|()[]", r):
+			w += 0.3
+		case strings.ContainsRune("mwMW@%", r):
+			w += 0.86
+		case unicode.IsUpper(r):
+			w += 0.68
+		default:
+			w += 0.5
+		}
+	}
+	if strings.Contains(font, "helvetica") || strings.Contains(font, "arial") || strings.Contains(font, "sans") {
+		w *= 1.08
+	}
+	return w * fontsize
+}
+
+var (
+	htmlBreak = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlTag   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// labelLines returns the lines of text of a label; the markup of HTML
+// labels is dropped.
+func labelLines(v interface{}) []string {
+	text, ok := plainLabel(v)
+	if !ok {
+		s := htmlBreak.ReplaceAllString(string(v.(HTML)), "\n")
+		text = strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(s, "")))
+	}
+	if len(text) == 0 {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// labelSize estimates the width and height in points of the label of an
+// element, from its font and lines of text.
+func labelSize(attrs map[string]interface{}) (float64, float64) {
+	size, name := fontOf(attrs)
+	lines := labelLines(attrs["label"])
+	w := 0.0
+	for _, each := range lines {
+		w = math.Max(w, textWidth(each, size, name))
+	}
+	return w, float64(len(lines)) * size * 1.2
+}
+
+// roundShape reports whether the outline of a shape is an ellipse.
+func roundShape(shape string) bool {
+	switch shape {
+	case "ellipse", "oval", "circle", "doublecircle", "point", "Mcircle":
+		return true
+	}
+	return false
+}
+
+// nodeSize returns the width and height in points of a node: its label
+// with margins, enlarged to fit its shape and to the `width` and `height`
+// minimums (0.75 and 0.5 inches by default), or exactly `width` and
+// `height` when `fixedsize` is set.
+func nodeSize(attrs map[string]interface{}) (float64, float64) {
+	shape := shapeOf(attrs)
+	width, hasWidth := numericValue(attrs["width"])
+	height, hasHeight := numericValue(attrs["height"])
+	if !hasWidth {
+		width = 0.75
+	}
+	if !hasHeight {
+		height = 0.5
+	}
+	if shape == "point" {
+		size := 0.05
+		if hasWidth || hasHeight {
+			size = math.Max(width, height)
+		}
+		return size * pointsPerInch, size * pointsPerInch
+	}
+	width *= pointsPerInch
+	height *= pointsPerInch
+
+	if fixed := attrString(attrs["fixedsize"]); fixed != "true" && fixed != "shape" {
+		w, h := labelSize(attrs)
+		if shape == "plain" {
+			if !hasWidth {
+				width = 0
+			}
+			if !hasHeight {
+				height = 0
+			}
+		} else {
+			w, h = w+16, h+8
+		}
+		switch {
+		case roundShape(shape):
+			w, h = w*math.Sqrt2, h*math.Sqrt2
+		case shape == "diamond":
+			w, h = w*2, h*2
+		}
+		width = math.Max(width, w)
+		height = math.Max(height, h)
+	}
+	switch shape {
+	case "circle", "doublecircle", "square":
+		width = math.Max(width, height)
+		height = width
+	}
+	if shape == "doublecircle" {
+		width, height = width+8, height+8
+	}
+	return width, height
+}
+
+// clipToNode returns the point where the segment from the center c of a
+// node toward p crosses the node outline, p itself when inside the node.
+func clipToNode(c point, w, h float64, shape string, p point) point {
+	dx, dy := p.x-c.x, p.y-c.y
+	a, b := w/2, h/2
+	if (dx == 0 && dy == 0) || a <= 0 || b <= 0 {
+		return c
+	}
+	var t float64
+	switch {
+	case roundShape(shape):
+		t = 1 / math.Sqrt(dx*dx/(a*a)+dy*dy/(b*b))
+	case shape == "diamond":
+		t = 1 / (math.Abs(dx)/a + math.Abs(dy)/b)
+	default:
+		t = math.Inf(1)
+		if dx != 0 {
+			t = a / math.Abs(dx)
+		}
+		if dy != 0 {
+			t = math.Min(t, b/math.Abs(dy))
+		}
+	}
+	if t >= 1 {
+		return p
+	}
+	return point{c.x + dx*t, c.y + dy*t}
+}
+
+// spline is the route of an edge: a piecewise cubic Bézier curve, 3n+1
+// points, and the tips of its arrowheads, if any.
+type spline struct {
+	points     []point
+	start, end *point
+}
+
+// newSpline returns a smooth curve through the points of a polyline,
+// shortened at the ends that have an arrowhead.
+func newSpline(route []point, startArrow, endArrow bool) spline {
+	pts := append([]point{}, route...)
+	s := spline{}
+	n := len(pts)
+	if endArrow {
+		tip := pts[n-1]
+		pts[n-1] = towards(tip, pts[n-2], arrowLength)
+		s.end = &tip
+	}
+	if startArrow {
+		tip := pts[0]
+		pts[0] = towards(tip, pts[1], arrowLength)
+		s.start = &tip
+	}
+	// Catmull-Rom interpolation converted to Bézier control points
+	s.points = []point{pts[0]}
+	for i := 0; i+1 < n; i++ {
+		p0, p1, p2, p3 := pts[i], pts[i], pts[i+1], pts[i+1]
+		if i > 0 {
+			p0 = pts[i-1]
+		}
+		if i+2 < n {
+			p3 = pts[i+2]
+		}
+		s.points = append(s.points,
+			point{p1.x + (p2.x-p0.x)/6, p1.y + (p2.y-p0.y)/6},
+			point{p2.x - (p3.x-p1.x)/6, p2.y - (p3.y-p1.y)/6},
+			p2)
+	}
+	return s
+}
+
+// towards moves p toward q by d, at most half the way.
+func towards(p, q point, d float64) point {
+	length := math.Hypot(q.x-p.x, q.y-p.y)
+	if length == 0 {
+		return p
+	}
+	d = math.Min(d, length/2)
+	return point{p.x + (q.x-p.x)*d/length, p.y + (q.y-p.y)*d/length}
+}
+
+// bezierAt evaluates a cubic Bézier curve.
+func bezierAt(p0, p1, p2, p3 point, t float64) point {
+	s := 1 - t
+	a, b, c, d := s*s*s, 3*s*s*t, 3*s*t*t, t*t*t
+	return point{a*p0.x + b*p1.x + c*p2.x + d*p3.x, a*p0.y + b*p1.y + c*p2.y + d*p3.y}
+}
+
+// midpoint returns the point halfway along the pieces of the curve.
+func (s spline) midpoint() point {
+	pieces := (len(s.points) - 1) / 3
+	k := pieces / 2
+	if pieces%2 == 0 {
+		return s.points[3*k]
+	}
+	return bezierAt(s.points[3*k], s.points[3*k+1], s.points[3*k+2], s.points[3*k+3], 0.5)
+}
+
+func (s spline) bounds() box {
+	res := emptyBox()
+	for _, p := range s.points {
+		res.add(p)
+	}
+	for _, tip := range []*point{s.start, s.end} {
+		if tip != nil {
+			res.add(*tip)
+		}
+	}
+	return res
+}
+
+func (s spline) translate(dx, dy float64) spline {
+	res := spline{points: make([]point, len(s.points))}
+	for i, p := range s.points {
+		res.points[i] = point{p.x + dx, p.y + dy}
+	}
+	if s.start != nil {
+		res.start = &point{s.start.x + dx, s.start.y + dy}
+	}
+	if s.end != nil {
+		res.end = &point{s.end.x + dx, s.end.y + dy}
+	}
+	return res
+}
+
+// String returns the spline in the format of the edge `pos` attribute.
+func (s spline) String() string {
+	parts := []string{}
+	if s.end != nil {
+		parts = append(parts, "e,"+formatPoint(*s.end))
+	}
+	if s.start != nil {
+		parts = append(parts, "s,"+formatPoint(*s.start))
+	}
+	for _, p := range s.points {
+		parts = append(parts, formatPoint(p))
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseSpline parses the `pos` attribute of an edge; of several splines,
+// only the first one is returned.
+func parseSpline(v interface{}) (spline, bool) {
+	text := strings.Trim(attrString(v), `" `)
+	if i := strings.Index(text, ";"); i >= 0 {
+		text = text[:i]
+	}
+	s := spline{}
+	for _, field := range strings.Fields(text) {
+		tip := strings.HasPrefix(field, "e,") || strings.HasPrefix(field, "s,")
+		xy, ok := parseFloats(strings.TrimLeft(field, "es,"))
+		if !ok || len(xy) < 2 {
+			return spline{}, false
+		}
+		p := point{xy[0], xy[1]}
+		switch {
+		case tip && field[0] == 'e':
+			s.end = &p
+		case tip:
+			s.start = &p
+		default:
+			s.points = append(s.points, p)
+		}
+	}
+	if len(s.points) < 4 || (len(s.points)-1)%3 != 0 {
+		return spline{}, false
+	}
+	return s, true
+}
+
+// geometry is the outcome of a layout engine: the center and size of the
+// nodes, the routes of the edges as polylines from the center of their tail
+// to the center of their head, and the cluster boxes, empty for engines
+// that do not draw clusters. Coordinates can have any origin; write
+// translates them so that the bounding box starts at 0,0, unless the
+// origin is kept because some nodes are pinned; pinned nodes keep the
+// trailing ! of their `pos`.
+type geometry struct {
+	adj       *adjacency
+	st        *styler
+	nodeAttrs []map[string]interface{}
+	center    []point
+	size      []point
+	pinned    []bool
+	// routes by edge index, nil for loops
+	routes     [][]point
+	clusters   []*Graph
+	boxes      []box
+	keepOrigin bool
+}
+
+func newGeometry(root *Graph) *geometry {
+	geo := &geometry{adj: newAdjacency(root), st: newStyler(root)}
+	for _, n := range geo.adj.nodes {
+		attrs := effectiveNodeAttributes(n, geo.st)
+		w, h := nodeSize(attrs)
+		geo.nodeAttrs = append(geo.nodeAttrs, attrs)
+		geo.size = append(geo.size, point{w, h})
+	}
+	geo.center = make([]point, len(geo.adj.nodes))
+	geo.pinned = make([]bool, len(geo.adj.nodes))
+	geo.routes = make([][]point, len(geo.adj.edges))
+	geo.clusters = clusterGraphs(root)
+	for range geo.clusters {
+		geo.boxes = append(geo.boxes, emptyBox())
+	}
+	return geo
+}
+
+// initialPosition returns the `pos` of a node, in points, and whether the
+// node is pinned there, by a trailing ! or by `pin`.
+func initialPosition(attrs map[string]interface{}) (p point, pinned bool, ok bool) {
+	pos, ok := parseFloats(attrs["pos"])
+	if !ok || len(pos) < 2 {
+		return point{}, false, false
+	}
+	pinned = strings.HasSuffix(strings.Trim(attrString(attrs["pos"]), `" `), "!") || attrString(attrs["pin"]) == "true"
+	return point{pos[0], pos[1]}, pinned, true
+}
+
+// straightRoutes routes every edge as a straight line.
+func (geo *geometry) straightRoutes() {
+	for e := range geo.adj.edges {
+		if u, v := geo.adj.from[e], geo.adj.to[e]; u != v {
+			geo.routes[e] = []point{geo.center[u], geo.center[v]}
+		}
+	}
+}
+
+// components returns the connected components of the graph, ignoring the
+// direction of the edges, each in depth first order. Skipped nodes are left
+// out and break the components.
+func (geo *geometry) components(skip []bool) [][]int {
+	seen := make([]bool, len(geo.adj.nodes))
+	var visit func(u int, comp []int) []int
+	visit = func(u int, comp []int) []int {
+		seen[u] = true
+		comp = append(comp, u)
+		for _, w := range geo.adj.neighbors(u, Both) {
+			if !seen[w] && (skip == nil || !skip[w]) {
+				comp = visit(w, comp)
+			}
+		}
+		return comp
+	}
+	res := [][]int{}
+	for u := range geo.adj.nodes {
+		if !seen[u] && (skip == nil || !skip[u]) {
+			res = append(res, visit(u, nil))
+		}
+	}
+	return res
+}
+
+// pack moves the components side by side, from left to right and gap
+// apart, centered on the x axis.
+func (geo *geometry) pack(comps [][]int, left, gap float64) {
+	for _, comp := range comps {
+		b := emptyBox()
+		for _, i := range comp {
+			b.addBox(centered(geo.center[i], geo.size[i].x, geo.size[i].y))
+		}
+		dx, dy := left-b.llx, -(b.lly+b.ury)/2
+		for _, i := range comp {
+			geo.center[i] = point{geo.center[i].x + dx, geo.center[i].y + dy}
+		}
+		left += b.urx - b.llx + gap
+	}
+}
+
+// clusterGraphs returns the subgraphs drawn as boxes, those with an
+// identifier starting with "cluster", depth first.
+func clusterGraphs(root *Graph) []*Graph {
+	res := []*Graph{}
+	root.walkGraphs(func(each *Graph) {
+		if each != root && strings.HasPrefix(each.id, "cluster") {
+			res = append(res, each)
+		}
+	})
+	return res
+}
+
+// loop returns a self loop on the right side of a node.
+func (geo *geometry) loop(i int, startArrow, endArrow bool) spline {
+	c, a, b := geo.center[i], geo.size[i].x/2, geo.size[i].y/2
+	x := c.x + a
+	if roundShape(shapeOf(geo.nodeAttrs[i])) {
+		x = c.x + a*math.Sqrt(8)/3
+	}
+	p0, p3 := point{x, c.y + b/3}, point{x, c.y - b/3}
+	s := spline{}
+	if startArrow {
+		tip := p0
+		p0 = point{x + arrowLength*0.7, p0.y + arrowLength*0.7}
+		s.start = &tip
+	}
+	if endArrow {
+		tip := p3
+		p3 = point{x + arrowLength*0.7, p3.y - arrowLength*0.7}
+		s.end = &tip
+	}
+	s.points = []point{p0, {c.x + a + 28, c.y + b + 12}, {c.x + a + 28, c.y - b - 12}, p3}
+	return s
+}
+
+// write stores the geometry in the attributes of the nodes, edges, clusters
+// and of the graph, together with the position `lp` of the labels of
+// edges, clusters and graph.
+func (geo *geometry) write(root *Graph) {
+	adj := geo.adj
+	bounds := emptyBox()
+	for i := range adj.nodes {
+		bounds.addBox(centered(geo.center[i], geo.size[i].x, geo.size[i].y))
+	}
+
+	splines := make([]spline, len(adj.edges))
+	labels := make([]*point, len(adj.edges))
+	for i, e := range adj.edges {
+		attrs := effectiveEdgeAttributes(e, geo.st)
+		dir := edgeDir(e, attrs)
+		startArrow, endArrow := dir == "back" || dir == "both", dir == "forward" || dir == "both"
+		u, v := adj.from[i], adj.to[i]
+		if route := geo.routes[i]; u != v && len(route) > 1 {
+			pts := append([]point{}, route...)
+			last := len(pts) - 1
+			pts[0] = clipToNode(geo.center[u], geo.size[u].x, geo.size[u].y, shapeOf(geo.nodeAttrs[u]), pts[1])
+			pts[last] = clipToNode(geo.center[v], geo.size[v].x, geo.size[v].y, shapeOf(geo.nodeAttrs[v]), pts[last-1])
+			splines[i] = newSpline(pts, startArrow, endArrow)
+		} else {
+			splines[i] = geo.loop(u, startArrow, endArrow)
+		}
+		bounds.addBox(splines[i].bounds())
+		if w, h := labelSize(attrs); w > 0 {
+			mid := splines[i].midpoint()
+			lp := point{mid.x + w/2 + 4, mid.y}
+			labels[i] = &lp
+			bounds.addBox(centered(lp, w, h))
+		}
+	}
+
+	clusterLabels := make([]*point, len(geo.clusters))
+	for i, c := range geo.clusters {
+		b := geo.boxes[i]
+		bounds.addBox(b)
+		if w, h := labelSize(geo.st.attributes(graphSelectable(c))); w > 0 && !b.empty() {
+			clusterLabels[i] = &point{(b.llx + b.urx) / 2, b.ury - clusterMargin/2 - h/2}
+		}
+	}
+	if bounds.empty() {
+		bounds = box{}
+	}
+	var rootLabel *point
+	if w, h := labelSize(geo.st.attributes(graphSelectable(root))); w > 0 {
+		rootLabel = &point{(bounds.llx + bounds.urx) / 2, bounds.lly - 4 - h/2}
+		bounds.addBox(centered(*rootLabel, w, h+8))
+	}
+
+	dx, dy := -bounds.llx, -bounds.lly
+	if geo.keepOrigin {
+		dx, dy = 0, 0
+	}
+	shift := func(p point) string { return formatPoint(point{p.x + dx, p.y + dy}) }
+	for i, n := range adj.nodes {
+		pos := shift(geo.center[i])
+		if geo.pinned[i] {
+			pos += "!"
+		}
+		n.Attr("pos", pos)
+		n.Attr("width", formatCoord(geo.size[i].x/pointsPerInch))
+		n.Attr("height", formatCoord(geo.size[i].y/pointsPerInch))
+	}
+	for i, e := range adj.edges {
+		e.Attr("pos", splines[i].translate(dx, dy).String())
+		if labels[i] != nil {
+			e.Attr("lp", shift(*labels[i]))
+		}
+	}
+	for i, c := range geo.clusters {
+		b := geo.boxes[i]
+		if b.empty() {
+			c.Delete("bb")
+			c.Delete("lp")
+			continue
+		}
+		c.Attr("bb", box{b.llx + dx, b.lly + dy, b.urx + dx, b.ury + dy}.String())
+		if clusterLabels[i] != nil {
+			c.Attr("lp", shift(*clusterLabels[i]))
+		}
+	}
+	root.Attr("bb", box{bounds.llx + dx, bounds.lly + dy, bounds.urx + dx, bounds.ury + dy}.String())
+	if rootLabel != nil {
+		root.Attr("lp", shift(*rootLabel))
+	}
+}
@@ -0,0 +1,96 @@
+package dot
+
+import (
+	"testing"
+)
+
+func TestTextWidth(t *testing.T) {
+	if got, want := textWidth("abc", 10, "Courier"), 18.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	narrow, wide := textWidth("iii", 14, "Times-Roman"), textWidth("MMM", 14, "Times-Roman")
+	if narrow >= wide {
+		t.Errorf("got [%v] want less than [%v]", narrow, wide)
+	}
+	if got, bigger := textWidth("abc", 14, "Times-Roman"), textWidth("abc", 28, "Times-Roman"); bigger != 2*got {
+		t.Errorf("got [%v] want [%v]", bigger, 2*got)
+	}
+}
+
+func TestLabelLines(t *testing.T) {
+	if got, want := len(labelLines(`one\ntwo`)), 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	lines := labelLines(HTML("<b>one</b><br/>t&amp;o"))
+	if got, want := len(lines), 2; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := lines[1], "t&o"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestNodeSize(t *testing.T) {
+	w, h := nodeSize(map[string]interface{}{"label": "a"})
+	if got, want := formatPoint(point{w, h}), "54,36"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	w, h = nodeSize(map[string]interface{}{"label": "a rather long label", "shape": "box"})
+	if w <= 54 || h != 36 {
+		t.Errorf("got [%v,%v] want wider than the minimum", w, h)
+	}
+	w, h = nodeSize(map[string]interface{}{"label": "a rather long label", "fixedsize": true, "width": 1, "height": 1})
+	if got, want := formatPoint(point{w, h}), "72,72"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	w, h = nodeSize(map[string]interface{}{"label": "a", "shape": "circle"})
+	if w != h {
+		t.Errorf("got [%v,%v] want a square", w, h)
+	}
+}
+
+func TestClipToNode(t *testing.T) {
+	c := point{0, 0}
+	if got, want := clipToNode(c, 20, 10, "box", point{100, 0}), (point{10, 0}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := clipToNode(c, 20, 10, "ellipse", point{0, -100}), (point{0, -5}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := clipToNode(c, 20, 10, "box", point{2, 1}), (point{2, 1}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestSplineString(t *testing.T) {
+	s := newSpline([]point{{0, 0}, {0, 30}}, false, true)
+	if got, want := s.String(), "e,0,30 0,0 0,3.33 0,16.67 0,20"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := s.midpoint(), (point{0, 10}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	s = newSpline([]point{{0, 0}, {10, 10}, {20, 0}}, true, false)
+	if got, want := len(s.points), 7; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if s.end != nil || s.start == nil {
+		t.Errorf("got [%v,%v] want a start arrow only", s.start, s.end)
+	}
+}
+
+func TestParseSpline(t *testing.T) {
+	s, ok := parseSpline("e,10,0 0,0 1,0 2,0 3,0")
+	if !ok {
+		t.Fatal("got no spline")
+	}
+	if got, want := *s.end, (point{10, 0}); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(s.points), 4; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if _, ok := parseSpline("0,0 1,0"); ok {
+		t.Errorf("got [%v] want [%v]", ok, false)
+	}
+}
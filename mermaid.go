@@ -0,0 +1,252 @@
+package dot
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var mermaidDirections = map[string]string{"TB": "TD", "BT": "BT", "LR": "LR", "RL": "RL"}
+
+// mermaidShapes maps dot shapes to the Mermaid node delimiters.
+var mermaidShapes = map[string][2]string{
+	"box":           {"[", "]"},
+	"rect":          {"[", "]"},
+	"rectangle":     {"[", "]"},
+	"square":        {"[", "]"},
+	"ellipse":       {"([", "])"},
+	"oval":          {"([", "])"},
+	"circle":        {"((", "))"},
+	"point":         {"((", "))"},
+	"doublecircle":  {"(((", ")))"},
+	"diamond":       {"{", "}"},
+	"hexagon":       {"{{", "}}"},
+	"parallelogram": {"[/", "/]"},
+	"trapezium":     {"[/", `\]`},
+	"invtrapezium":  {`[\`, "/]"},
+	"cylinder":      {"[(", ")]"},
+	"cds":           {">", "]"},
+	"component":     {"[[", "]]"},
+}
+
+// mermaidLinks holds the links without arrow, with one arrow and with two
+// arrows for each line style.
+var mermaidLinks = map[string][3]string{
+	"solid":     {"---", "-->", "<-->"},
+	"dotted":    {"-.-", "-.->", "<-.->"},
+	"thick":     {"===", "==>", "<==>"},
+	"invisible": {"~~~", "~~~", "~~~"},
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart: rankdir becomes the
+// direction, clusters become subgraph blocks, common shapes, colors and
+// styles are translated, URL and tooltip become click directives.
+// Attributes set by the stylesheet in use, if any, are translated too.
+// The report lists the attributes that have no Mermaid equivalent.
+func (g *Graph) WriteMermaid(w io.Writer) (*ExportReport, error) {
+	m := &mermaidWriter{b: new(strings.Builder), report: &ExportReport{}, st: newStyler(g.Root()), ids: containerIDs(g)}
+	m.graph(g)
+	_, err := io.WriteString(w, m.b.String())
+	return m.report, err
+}
+
+type mermaidWriter struct {
+	b      *strings.Builder
+	st     *styler
+	ids    map[*Graph]string
+	report *ExportReport
+	styles []string
+	clicks []string
+}
+
+func (m *mermaidWriter) graph(g *Graph) {
+	attrs := m.st.attributes(graphSelectable(g))
+	translated := map[string]bool{"rankdir": true, "label": true}
+	if label, ok := plainLabel(attrs["label"]); ok && len(label) > 0 {
+		fmt.Fprintf(m.b, "---\ntitle: %s\n---\n", strings.Replace(label, "\n", " ", -1))
+	} else if !ok {
+		translated["label"] = false
+	}
+	direction := "TD"
+	if d, ok := mermaidDirections[fmt.Sprint(attrs["rankdir"])]; ok {
+		direction = d
+	} else if attrs["rankdir"] != nil {
+		translated["rankdir"] = false
+	}
+	m.report.untranslated("graph", attrs, translated)
+	fmt.Fprintf(m.b, "flowchart %s\n", direction)
+
+	m.content(g, "    ")
+
+	edges := g.allEdges()
+	for i, e := range edges {
+		m.edge(i, e)
+	}
+	for _, each := range m.styles {
+		fmt.Fprintf(m.b, "    %s\n", each)
+	}
+	for _, each := range m.clicks {
+		fmt.Fprintf(m.b, "    %s\n", each)
+	}
+}
+
+func (m *mermaidWriter) content(g *Graph, indent string) {
+	for _, n := range nodesBySeq(g) {
+		m.node(n, indent)
+	}
+	m.report.sameRank(g, len(g.sameRank))
+	for _, sub := range g.orderedSubgraphs() {
+		attrs := m.st.attributes(graphSelectable(sub))
+		id := m.ids[sub]
+		translated := map[string]bool{"label": true}
+		title := sub.id
+		if label, ok := plainLabel(attrs["label"]); ok {
+			title = label
+		} else {
+			translated["label"] = false
+		}
+		fmt.Fprintf(m.b, "%ssubgraph %s [%s]\n", indent, id, mermaidText(title))
+		m.style(id, attrs, translated)
+		m.report.untranslated("subgraph "+sub.id, attrs, translated)
+		m.content(sub, indent+"    ")
+		fmt.Fprintf(m.b, "%send\n", indent)
+	}
+}
+
+func (m *mermaidWriter) node(n *Node, indent string) {
+	attrs := effectiveNodeAttributes(n, m.st)
+	translated := map[string]bool{"label": true, "shape": true}
+	id := fmt.Sprintf("n%d", n.seq)
+
+	text, ok := plainLabel(attrs["label"])
+	if !ok {
+		text = n.id
+		translated["label"] = false
+	}
+	shape := shapeOf(attrs)
+	delims, ok := mermaidShapes[shape]
+	if !ok {
+		delims = mermaidShapes["box"]
+		translated["shape"] = false
+	}
+	if (shape == "box" || shape == "rect" || shape == "rectangle") && styleWords(attrs["style"])["rounded"] {
+		delims = [2]string{"(", ")"}
+	}
+	fmt.Fprintf(m.b, "%s%s%s%s%s\n", indent, id, delims[0], mermaidText(text), delims[1])
+
+	m.style(id, attrs, translated)
+	if url, ok := attrs["URL"]; ok {
+		translated["URL"] = true
+		click := fmt.Sprintf("click %s href %s", id, mermaidString(fmt.Sprint(url)))
+		if tooltip, ok := attrs["tooltip"]; ok {
+			translated["tooltip"] = true
+			click += " " + mermaidString(fmt.Sprint(tooltip))
+		}
+		m.clicks = append(m.clicks, click)
+	}
+	m.report.untranslated("node "+n.id, attrs, translated)
+}
+
+// style adds the style directive for the colors and line style of a node or subgraph.
+func (m *mermaidWriter) style(id string, attrs map[string]interface{}, translated map[string]bool) {
+	s := newExportStyle(attrs, translated)
+	if s.invisible {
+		translated["style"] = false
+	}
+	props := []string{}
+	if len(s.fill) > 0 {
+		props = append(props, "fill:"+s.fill)
+	}
+	if len(s.stroke) > 0 {
+		props = append(props, "stroke:"+s.stroke)
+	}
+	if len(s.font) > 0 {
+		props = append(props, "color:"+s.font)
+	}
+	if s.width > 0 {
+		props = append(props, fmt.Sprintf("stroke-width:%spx", formatFloat(s.width)))
+	} else if s.bold {
+		props = append(props, "stroke-width:2px")
+	}
+	if s.dashed {
+		props = append(props, "stroke-dasharray:5 5")
+	}
+	if s.dotted {
+		props = append(props, "stroke-dasharray:2 2")
+	}
+	if len(props) > 0 {
+		m.styles = append(m.styles, fmt.Sprintf("style %s %s", id, strings.Join(props, ",")))
+	}
+}
+
+func (m *mermaidWriter) edge(i int, e *Edge) {
+	attrs := effectiveEdgeAttributes(e, m.st)
+	translated := map[string]bool{"label": true, "dir": true, "arrowhead": attrs["arrowhead"] == "none"}
+	s := newExportStyle(attrs, translated)
+
+	links := mermaidLinks["solid"]
+	switch {
+	case s.invisible:
+		links = mermaidLinks["invisible"]
+	case s.dashed || s.dotted:
+		links = mermaidLinks["dotted"]
+	case s.bold || s.width >= 2:
+		links = mermaidLinks["thick"]
+	}
+	from, to := e.from, e.to
+	link := links[0]
+	switch edgeDir(e, attrs) {
+	case "forward":
+		link = links[1]
+	case "back":
+		link = links[1]
+		from, to = to, from
+	case "both":
+		link = links[2]
+	}
+
+	if label, ok := plainLabel(attrs["label"]); !ok {
+		translated["label"] = false
+	} else if len(label) > 0 {
+		link += "|" + mermaidText(label) + "|"
+	}
+	fmt.Fprintf(m.b, "    n%d %s n%d\n", from.seq, link, to.seq)
+
+	props := []string{}
+	if len(s.stroke) > 0 {
+		props = append(props, "stroke:"+s.stroke)
+	}
+	if len(s.font) > 0 {
+		props = append(props, "color:"+s.font)
+	}
+	if s.width > 0 {
+		props = append(props, fmt.Sprintf("stroke-width:%spx", formatFloat(s.width)))
+	}
+	if len(props) > 0 {
+		m.styles = append(m.styles, fmt.Sprintf("linkStyle %d %s", i, strings.Join(props, ",")))
+	}
+	m.report.untranslated(e.element(), attrs, translated)
+}
+
+// nodesBySeq returns the nodes of the graph, without its subgraphs, ordered
+// by sequence number.
+func nodesBySeq(g *Graph) []*Node {
+	nodes := make([]*Node, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		n := n
+		nodes = append(nodes, &n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].seq < nodes[j].seq })
+	return nodes
+}
+
+// mermaidText quotes a label, using entity codes for quotes and <br> for line breaks.
+func mermaidText(s string) string {
+	s = strings.Replace(s, `"`, "#quot;", -1)
+	return `"` + strings.Replace(s, "\n", "<br>", -1) + `"`
+}
+
+func mermaidString(s string) string {
+	return `"` + strings.Replace(s, `"`, "#quot;", -1) + `"`
+}
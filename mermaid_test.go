@@ -0,0 +1,83 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteMermaid(t *testing.T) {
+	g := NewGraph(Directed)
+	g.Attr("rankdir", "LR")
+	g.Attr("label", "Flow")
+	a := g.Node(WithLabel("start"), func(a *AttributesMap) {
+		a.Attr("shape", "circle")
+		a.Attr("fontname", "Arial")
+	})
+	sub := g.NewSubgraph()
+	sub.Attr("label", "Work")
+	sub.Attr("bgcolor", "#eeeeee")
+	b := sub.Node(WithLabel(`say "hi"`), func(a *AttributesMap) {
+		a.Attr("shape", "box")
+		a.Attr("style", "rounded,filled")
+		a.Attr("fillcolor", "yellow")
+		a.Attr("URL", "https://example.com")
+	})
+	g.Edge(a, b, WithLabel("go"), func(a *AttributesMap) {
+		a.Attr("style", "dashed")
+		a.Attr("color", "red")
+	})
+	g.Edge(b, a, func(a *AttributesMap) { a.Attr("dir", "both") })
+
+	buf := new(bytes.Buffer)
+	report, err := g.WriteMermaid(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `---
+title: Flow
+---
+flowchart LR
+    n1(("start"))
+    subgraph cluster_2 ["Work"]
+        n3("say #quot;hi#quot;")
+    end
+    n1 -.->|"go"| n3
+    n3 <--> n1
+    style cluster_2 fill:#eeeeee
+    style n3 fill:yellow
+    linkStyle 0 stroke:red
+    click n3 href "https://example.com"
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := report.String(), "node n1: fontname=Arial\n"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestWriteMermaidUndirected(t *testing.T) {
+	g := NewGraph(Undirected)
+	g.NodeBaseAttrs().Attr("shape", "star")
+	a := g.Node(WithLabel("a"))
+	b := g.Node(WithLabel("b"))
+	g.Edge(a, b, func(a *AttributesMap) { a.Attr("penwidth", 3) })
+
+	buf := new(bytes.Buffer)
+	report, err := g.WriteMermaid(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `flowchart TD
+    n1["a"]
+    n2["b"]
+    n1 === n2
+    linkStyle 0 stroke-width:3px
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(report.Untranslated), 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package dot
+
+// Direction selects which edges are followed when walking the graph.
+type Direction int
+
+const (
+	// Downstream follows edges from their tail to their head.
+	Downstream Direction = iota
+	// Upstream follows edges from their head back to their tail.
+	Upstream
+	// Both follows edges in both directions.
+	Both
+)
+
+// Neighborhood returns a new graph induced by the seed nodes and by all the
+// nodes within depth hops of them, walking edges in the given direction.
+// A negative depth does not limit the walk. The returned graph keeps the
+// attributes, the node global attributes, the subgraphs holding at least one
+// of the selected nodes, the rank groups and the edges between selected nodes.
+func (g *Graph) Neighborhood(seeds []*Node, depth int, dir Direction) *Graph {
+	adj := newAdjacency(g)
+
+	dist := make([]int, len(adj.nodes))
+	for i := range dist {
+		dist[i] = -1
+	}
+	queue := []int{}
+	for _, n := range seeds {
+		if u := adj.nodeIndex(n); u >= 0 && dist[u] < 0 {
+			dist[u] = 0
+			queue = append(queue, u)
+		}
+	}
+	for i := 0; i < len(queue); i++ {
+		u := queue[i]
+		if depth >= 0 && dist[u] >= depth {
+			continue
+		}
+		for _, v := range adj.neighbors(u, dir) {
+			if dist[v] < 0 {
+				dist[v] = dist[u] + 1
+				queue = append(queue, v)
+			}
+		}
+	}
+
+	keep := map[string]bool{}
+	for _, u := range queue {
+		keep[adj.nodes[u].id] = true
+	}
+	c := g.cloneWhere(func(n Node) bool { return keep[n.id] })
+	c.pruneEmptySubgraphs()
+	return c
+}
+
+// pruneEmptySubgraphs removes, recursively, the subgraphs without nodes.
+// It reports whether the graph itself is empty.
+func (g *Graph) pruneEmptySubgraphs() bool {
+	for id, sub := range g.subgraphs {
+		if sub.pruneEmptySubgraphs() {
+			delete(g.subgraphs, id)
+		}
+	}
+	return len(g.nodes) == 0 && len(g.subgraphs) == 0
+}
@@ -0,0 +1,68 @@
+package dot
+
+import "testing"
+
+func TestNeighborhoodDownstream(t *testing.T) {
+	g := NewGraph(Directed)
+	g.NodeBaseAttrs().Attr("shape", "box")
+	web := g.NodeWithID("web")
+	payments := g.NodeWithID("payments")
+	sub := g.NewSubgraph()
+	sub.Label("storage")
+	ledger := sub.NodeWithID("ledger")
+	disk := sub.NodeWithID("disk")
+	other := g.NewSubgraph()
+	mail := other.NodeWithID("mail")
+	g.Edge(web, payments)
+	g.Edge(payments, ledger).Attr("color", "blue")
+	g.Edge(ledger, disk)
+	g.Edge(web, mail)
+
+	n := g.Neighborhood([]*Node{payments}, 1, Downstream)
+
+	for _, id := range []string{"payments", "ledger"} {
+		if n.FindNodeByID(id) == nil {
+			t.Errorf("missing node [%v]", id)
+		}
+	}
+	for _, id := range []string{"web", "disk", "mail"} {
+		if n.FindNodeByID(id) != nil {
+			t.Errorf("unexpected node [%v]", id)
+		}
+	}
+	if got, want := len(n.subgraphs), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if sub, ok := n.FindSubgraphByLabel("storage"); !ok || sub.nodes["ledger"].id != "ledger" {
+		t.Error("ledger must stay in the storage cluster")
+	}
+	edges := n.FindEdges(*n.FindNodeByID("payments"), *n.FindNodeByID("ledger"))
+	if got, want := len(edges), 1; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := edges[0].Value("color"), "blue"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := n.NodeBaseAttrs().Value("shape"), "box"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestNeighborhoodBoth(t *testing.T) {
+	g := NewGraph(Directed)
+	web, payments := g.NodeWithID("web"), g.NodeWithID("payments")
+	ledger, disk := g.NodeWithID("ledger"), g.NodeWithID("disk")
+	g.Edge(web, payments)
+	g.Edge(payments, ledger)
+	g.Edge(ledger, disk)
+	g.Edge(web, g.NodeWithID("mail"))
+
+	n := g.Neighborhood([]*Node{payments}, -1, Both)
+	if got, want := len(n.allNodes()), 5; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	n = g.Neighborhood([]*Node{payments}, 1, Upstream)
+	if got, want := nodeIDs(n.allNodes()), "web,payments"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
@@ -0,0 +1,261 @@
+package dot
+
+import (
+	"container/heap"
+	"math"
+)
+
+// Path is a walk through the graph: Edges[i] connects Nodes[i] to Nodes[i+1].
+type Path struct {
+	Nodes []*Node
+	Edges []*Edge
+	Cost  float64
+	// position of each edge among the edges with the same endpoints
+	parallel []int
+}
+
+// EdgeCost returns the cost of traversing an edge.
+type EdgeCost func(*Edge) float64
+
+// AttrCost returns an EdgeCost reading the numeric edge attribute with the
+// given name (e.g. "weight"), or fallback if it is missing or not a number.
+func AttrCost(name string, fallback float64) EdgeCost {
+	return func(e *Edge) float64 {
+		if f, ok := numericValue(e.Value(name)); ok {
+			return f
+		}
+		return fallback
+	}
+}
+
+// ShortestPath returns a path from one node to another with the fewest edges
+// (breadth-first search); the path cost is the number of edges.
+// It reports false if the destination cannot be reached.
+func (g *Graph) ShortestPath(from, to *Node) (Path, bool) {
+	adj := newAdjacency(g)
+	src, dst := adj.nodeIndex(from), adj.nodeIndex(to)
+	if src < 0 || dst < 0 {
+		return Path{}, false
+	}
+
+	via := make([]int, len(adj.nodes))
+	for i := range via {
+		via[i] = -1
+	}
+	seen := make([]bool, len(adj.nodes))
+	seen[src] = true
+	queue := []int{src}
+	for i := 0; i < len(queue) && !seen[dst]; i++ {
+		u := queue[i]
+		for _, v := range adj.successors(u) {
+			if !seen[v] {
+				seen[v] = true
+				via[v] = adj.edgesBetween(u, v)[0]
+				queue = append(queue, v)
+			}
+		}
+	}
+
+	if !seen[dst] {
+		return Path{}, false
+	}
+	p := adj.path(src, adj.backtrack(via, src, dst))
+	p.Cost = float64(len(p.Edges))
+	return p, true
+}
+
+// WeightedShortestPath returns the cheapest path from one node to another
+// (Dijkstra's algorithm) using the given edge cost; negative and NaN costs
+// count as zero.
+// Among paths with the same cost the one through earlier created nodes wins.
+// It reports false if the destination cannot be reached.
+func (g *Graph) WeightedShortestPath(from, to *Node, cost EdgeCost) (Path, bool) {
+	adj := newAdjacency(g)
+	src, dst := adj.nodeIndex(from), adj.nodeIndex(to)
+	if src < 0 || dst < 0 {
+		return Path{}, false
+	}
+
+	dist := make([]float64, len(adj.nodes))
+	via := make([]int, len(adj.nodes))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+		via[i] = -1
+	}
+	dist[src] = 0
+
+	queue := &costHeap{{node: src}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(costItem)
+		u := item.node
+		if item.cost > dist[u] {
+			continue
+		}
+		if u == dst {
+			break
+		}
+		for _, e := range adj.out[u] {
+			c := cost(adj.edges[e])
+			if c < 0 || math.IsNaN(c) {
+				c = 0
+			}
+			v := adj.to[e]
+			if d := dist[u] + c; d < dist[v] {
+				dist[v] = d
+				via[v] = e
+				heap.Push(queue, costItem{node: v, cost: d})
+			}
+		}
+	}
+
+	if math.IsInf(dist[dst], 1) {
+		return Path{}, false
+	}
+	p := adj.path(src, adj.backtrack(via, src, dst))
+	p.Cost = dist[dst]
+	return p, true
+}
+
+// AllSimplePaths returns the paths from one node to another that do not visit
+// any node twice, in depth-first order. Parallel edges do not produce distinct
+// paths. A positive maxLength limits the number of edges of each path and a
+// positive limit the number of returned paths. The path cost is the number of edges.
+func (g *Graph) AllSimplePaths(from, to *Node, maxLength, limit int) []Path {
+	adj := newAdjacency(g)
+	src, dst := adj.nodeIndex(from), adj.nodeIndex(to)
+	paths := []Path{}
+	if src < 0 || dst < 0 {
+		return paths
+	}
+
+	visited := make([]bool, len(adj.nodes))
+	edges := []int{}
+	var walk func(u int) bool
+	walk = func(u int) bool {
+		if u == dst && len(edges) > 0 {
+			p := adj.path(src, edges)
+			p.Cost = float64(len(edges))
+			paths = append(paths, p)
+			return limit > 0 && len(paths) >= limit
+		}
+		if maxLength > 0 && len(edges) >= maxLength {
+			return false
+		}
+		visited[u] = true
+		defer func() { visited[u] = false }()
+		for _, v := range adj.successors(u) {
+			if visited[v] && v != dst {
+				continue
+			}
+			edges = append(edges, adj.edgesBetween(u, v)[0])
+			done := walk(v)
+			edges = edges[:len(edges)-1]
+			if done {
+				return true
+			}
+		}
+		return false
+	}
+	walk(src)
+	return paths
+}
+
+// HighlightPath applies the highlight attributes to the nodes and edges along
+// the path and the dim attributes to everything else. Either can be nil.
+// Edges are matched by their endpoints and their position among parallel
+// edges, so the path still applies after adding edges to the graph.
+func (g *Graph) HighlightPath(p Path, highlight, dim func(*AttributesMap)) {
+	type edgeKey struct{ from, to, index int }
+	onPath := map[string]bool{}
+	for _, n := range p.Nodes {
+		onPath[n.id] = true
+	}
+	edges := map[edgeKey]bool{}
+	for i, e := range p.Edges {
+		key := edgeKey{from: e.from.seq, to: e.to.seq}
+		if i < len(p.parallel) {
+			key.index = p.parallel[i]
+		}
+		edges[key] = true
+	}
+
+	apply := func(a *AttributesMap, selected bool) {
+		if selected && highlight != nil {
+			highlight(a)
+		}
+		if !selected && dim != nil {
+			dim(a)
+		}
+	}
+	for _, n := range g.allNodes() {
+		apply(n.Attrs(), onPath[n.id])
+	}
+	seen := map[edgeKey]int{}
+	for _, e := range g.allEdges() {
+		pair := edgeKey{from: e.from.seq, to: e.to.seq}
+		key := edgeKey{from: pair.from, to: pair.to, index: seen[pair]}
+		seen[pair]++
+		apply(e.Attrs(), edges[key])
+	}
+}
+
+// backtrack returns the edges leading from src to dst, following the
+// incoming edge recorded for each node.
+func (adj *adjacency) backtrack(via []int, src, dst int) []int {
+	edges := []int{}
+	for v := dst; v != src; v = adj.from[via[v]] {
+		edges = append(edges, via[v])
+	}
+	for i, j := 0, len(edges)-1; i < j; i, j = i+1, j-1 {
+		edges[i], edges[j] = edges[j], edges[i]
+	}
+	return edges
+}
+
+// path builds a Path starting at src following the edge indexes.
+func (adj *adjacency) path(src int, edges []int) Path {
+	p := Path{Nodes: []*Node{adj.nodes[src]}}
+	for _, e := range edges {
+		p.Edges = append(p.Edges, adj.edges[e])
+		p.Nodes = append(p.Nodes, adj.nodes[adj.to[e]])
+		p.parallel = append(p.parallel, adj.parallelIndex(e))
+	}
+	return p
+}
+
+// parallelIndex returns the position of the edge among the edges with the
+// same endpoints, in the order of allEdges.
+func (adj *adjacency) parallelIndex(e int) int {
+	index := 0
+	for _, i := range adj.out[adj.from[e]] {
+		if i < e && adj.edges[i].from.seq == adj.edges[e].from.seq && adj.edges[i].to.seq == adj.edges[e].to.seq {
+			index++
+		}
+	}
+	return index
+}
+
+type costItem struct {
+	node int
+	cost float64
+}
+
+// costHeap is a min-heap of nodes by cost, then by index.
+type costHeap []costItem
+
+func (h costHeap) Len() int { return len(h) }
+func (h costHeap) Less(i, j int) bool {
+	if h[i].cost != h[j].cost {
+		return h[i].cost < h[j].cost
+	}
+	return h[i].node < h[j].node
+}
+func (h costHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *costHeap) Push(x interface{}) { *h = append(*h, x.(costItem)) }
+func (h *costHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,129 @@
+package dot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShortestPath(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b, c, z := g.NodeWithID("a"), g.NodeWithID("b"), g.NodeWithID("c"), g.NodeWithID("z")
+	g.Edge(a, z)
+	g.Edge(a, b)
+	g.Edge(b, c)
+	g.Edge(c, z)
+	p, ok := g.ShortestPath(a, z)
+	if !ok {
+		t.Fatal("expected a path")
+	}
+	if got, want := nodeIDs(p.Nodes), "a,z"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if _, ok := g.ShortestPath(z, a); ok {
+		t.Error("unexpected path")
+	}
+}
+
+func TestWeightedShortestPath(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b, c, z := g.NodeWithID("a"), g.NodeWithID("b"), g.NodeWithID("c"), g.NodeWithID("z")
+	g.Edge(a, z).Attr("weight", 10)
+	g.Edge(a, b).Attr("weight", "1")
+	g.Edge(b, c).Attr("weight", 2.5)
+	g.Edge(c, z).Attr("weight", 1)
+	p, ok := g.WeightedShortestPath(a, z, AttrCost("weight", 1))
+	if !ok {
+		t.Fatal("expected a path")
+	}
+	if got, want := nodeIDs(p.Nodes), "a,b,c,z"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := p.Cost, 4.5; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(p.Edges), 3; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestAllSimplePaths(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b, c, z := g.NodeWithID("a"), g.NodeWithID("b"), g.NodeWithID("c"), g.NodeWithID("z")
+	g.Edge(a, z)
+	g.Edge(a, b)
+	g.Edge(b, c)
+	g.Edge(c, z)
+	if got, want := len(g.AllSimplePaths(a, z, 0, 0)), 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(g.AllSimplePaths(a, z, 2, 0)), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(g.AllSimplePaths(a, z, 0, 1)), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestHighlightPath(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b, c, z := g.NodeWithID("a"), g.NodeWithID("b"), g.NodeWithID("c"), g.NodeWithID("z")
+	g.Edge(a, z).Attr("weight", 10)
+	g.Edge(a, b).Attr("weight", "1")
+	g.Edge(b, c).Attr("weight", 2.5)
+	g.Edge(c, z).Attr("weight", 1)
+	p, _ := g.WeightedShortestPath(a, z, AttrCost("weight", 1))
+	g.HighlightPath(p,
+		func(a *AttributesMap) { a.Attr("color", "red") },
+		func(a *AttributesMap) { a.Attr("color", "gray") })
+
+	if got, want := g.FindNodeByID("b").Value("color"), "red"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := g.FindEdges(*a, *z)[0].Value("color"), "gray"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := g.FindEdges(*b, *c)[0].Value("color"), "red"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestHighlightPathAfterAddingEdges(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b := g.NodeWithID("a"), g.NodeWithID("b")
+	g.Edge(a, b).Attr("weight", 5)
+	g.Edge(a, b).Attr("weight", 1)
+	p, _ := g.WeightedShortestPath(a, b, AttrCost("weight", 1))
+	for i := 0; i < 10; i++ {
+		g.Edge(a, b).Attr("weight", 1)
+	}
+	g.HighlightPath(p, func(a *AttributesMap) { a.Attr("color", "red") }, nil)
+
+	edges := g.FindEdges(*a, *b)
+	for i, e := range edges {
+		want := interface{}(nil)
+		if i == 1 {
+			want = "red"
+		}
+		if got := e.Value("color"); got != want {
+			t.Errorf("%d: got [%v] want [%v]", i, got, want)
+		}
+	}
+}
+
+func TestWeightedShortestPathNaN(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b, z := g.NodeWithID("a"), g.NodeWithID("b"), g.NodeWithID("z")
+	g.Edge(a, z).Attr("weight", 1)
+	g.Edge(a, b).Attr("weight", math.NaN())
+	g.Edge(b, z).Attr("weight", 0.5)
+	p, ok := g.WeightedShortestPath(a, z, AttrCost("weight", 1))
+	if !ok {
+		t.Fatal("expected a path")
+	}
+	if got, want := nodeIDs(p.Nodes), "a,b,z"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := p.Cost, 0.5; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
@@ -0,0 +1,223 @@
+package dot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// plantUMLShapes maps dot shapes to PlantUML deployment diagram elements.
+var plantUMLShapes = map[string]string{
+	"box":          "rectangle",
+	"rect":         "rectangle",
+	"rectangle":    "rectangle",
+	"square":       "rectangle",
+	"ellipse":      "usecase",
+	"oval":         "usecase",
+	"circle":       "circle",
+	"doublecircle": "circle",
+	"point":        "circle",
+	"cylinder":     "database",
+	"folder":       "folder",
+	"tab":          "folder",
+	"component":    "component",
+	"note":         "file",
+	"box3d":        "node",
+	"hexagon":      "hexagon",
+	"plaintext":    "label",
+	"plain":        "label",
+	"none":         "label",
+}
+
+// WritePlantUML writes the graph as a PlantUML deployment diagram: nodes
+// become elements chosen by shape, clusters become nested rectangles and
+// edges become arrows, with labels, colors, line styles and links.
+// Attributes set by the stylesheet in use, if any, are translated too.
+// The report lists the attributes that have no PlantUML equivalent.
+func (g *Graph) WritePlantUML(w io.Writer) (*ExportReport, error) {
+	p := &plantUMLWriter{b: new(strings.Builder), top: g, ids: containerIDs(g), st: newStyler(g.Root()), report: &ExportReport{}}
+	g.walk(p)
+	_, err := io.WriteString(w, p.b.String())
+	return p.report, err
+}
+
+type plantUMLWriter struct {
+	b      *strings.Builder
+	top    *Graph
+	ids    map[*Graph]string
+	st     *styler
+	report *ExportReport
+	indent string
+}
+
+func (p *plantUMLWriter) enterGraph(g *Graph) {
+	attrs := p.st.attributes(graphSelectable(g))
+	translated := map[string]bool{"label": true}
+	label, ok := plainLabel(attrs["label"])
+	if !ok {
+		translated["label"] = false
+	}
+
+	if g == p.top {
+		fmt.Fprintln(p.b, "@startuml")
+		if len(label) > 0 {
+			fmt.Fprintf(p.b, "title %s\n", plantUMLText(label))
+		}
+		switch attrs["rankdir"] {
+		case nil, "TB":
+			translated["rankdir"] = true
+		case "LR":
+			translated["rankdir"] = true
+			fmt.Fprintln(p.b, "left to right direction")
+		}
+		p.report.untranslated(graphElement(g), attrs, translated)
+		return
+	}
+
+	if len(label) == 0 {
+		label = g.id
+	}
+	fmt.Fprintf(p.b, "%srectangle \"%s\" as %s%s {\n", p.indent, plantUMLText(label), p.ids[g], p.style(attrs, translated))
+	p.report.untranslated(graphElement(g), attrs, translated)
+	p.indent += "  "
+}
+
+func (p *plantUMLWriter) nodes(g *Graph, nodes []*Node) {
+	for _, n := range nodes {
+		attrs := effectiveNodeAttributes(n, p.st)
+		translated := map[string]bool{"label": true, "shape": true}
+		label, ok := plainLabel(attrs["label"])
+		if !ok {
+			label = n.id
+			translated["label"] = false
+		}
+		kind, ok := plantUMLShapes[shapeOf(attrs)]
+		if !ok {
+			kind = "rectangle"
+			translated["shape"] = false
+		}
+
+		link := ""
+		if url, ok := attrs["URL"]; ok {
+			translated["URL"] = true
+			link = fmt.Sprint(url)
+			if tooltip, ok := attrs["tooltip"]; ok {
+				translated["tooltip"] = true
+				link += "{" + fmt.Sprint(tooltip) + "}"
+			}
+			link = " [[" + link + "]]"
+		}
+		fmt.Fprintf(p.b, "%s%s \"%s\" as n%d%s%s\n", p.indent, kind, plantUMLText(label), n.seq, link, p.style(attrs, translated))
+		p.report.untranslated("node "+n.id, attrs, translated)
+	}
+}
+
+// style returns the inline color and line style of an element, like
+// ` #back;line:color;line.dashed;text:color`.
+func (p *plantUMLWriter) style(attrs map[string]interface{}, translated map[string]bool) string {
+	s := newExportStyle(attrs, translated)
+	if s.invisible {
+		translated["style"] = false
+	}
+	props := []string{}
+	if len(s.fill) > 0 {
+		props = append(props, plantUMLColor(s.fill))
+	}
+	if len(s.stroke) > 0 {
+		props = append(props, "line:"+plantUMLColor(s.stroke))
+	}
+	switch {
+	case s.dashed:
+		props = append(props, "line.dashed")
+	case s.dotted:
+		props = append(props, "line.dotted")
+	case s.bold || s.width >= 2:
+		props = append(props, "line.bold")
+	}
+	if len(s.font) > 0 {
+		props = append(props, "text:"+plantUMLColor(s.font))
+	}
+	if len(props) == 0 {
+		return ""
+	}
+	return " #" + strings.Join(props, ";")
+}
+
+func (p *plantUMLWriter) edges(g *Graph, groups [][]*Edge) {
+	for _, group := range groups {
+		for _, e := range group {
+			attrs := effectiveEdgeAttributes(e, p.st)
+			translated := map[string]bool{"label": true, "dir": true, "arrowhead": attrs["arrowhead"] == "none"}
+			s := newExportStyle(attrs, translated)
+
+			options := []string{}
+			if len(s.stroke) > 0 {
+				options = append(options, "#"+plantUMLColor(s.stroke))
+			}
+			switch {
+			case s.invisible:
+				options = append(options, "hidden")
+			case s.dashed:
+				options = append(options, "dashed")
+			case s.dotted:
+				options = append(options, "dotted")
+			case s.bold:
+				options = append(options, "bold")
+			}
+			if s.width > 0 {
+				options = append(options, "thickness="+formatFloat(s.width))
+			}
+			if len(s.font) > 0 {
+				translated["fontcolor"] = false
+			}
+			line := "-"
+			if len(options) > 0 {
+				line += "[" + strings.Join(options, ",") + "]"
+			}
+
+			from, to := e.from, e.to
+			arrow := line + "-"
+			switch edgeDir(e, attrs) {
+			case "forward":
+				arrow = line + "->"
+			case "back":
+				arrow = line + "->"
+				from, to = to, from
+			case "both":
+				arrow = "<" + line + "->"
+			}
+
+			fmt.Fprintf(p.b, "%sn%d %s n%d", p.indent, from.seq, arrow, to.seq)
+			if label, ok := plainLabel(attrs["label"]); !ok {
+				translated["label"] = false
+			} else if len(label) > 0 {
+				fmt.Fprintf(p.b, " : %s", plantUMLText(label))
+			}
+			fmt.Fprintln(p.b)
+			p.report.untranslated(e.element(), attrs, translated)
+		}
+	}
+}
+
+func (p *plantUMLWriter) sameRank(g *Graph, groups [][]*Node) {
+	p.report.sameRank(g, len(groups))
+}
+
+func (p *plantUMLWriter) leaveGraph(g *Graph) {
+	if g == p.top {
+		fmt.Fprintln(p.b, "@enduml")
+		return
+	}
+	p.indent = p.indent[2:]
+	fmt.Fprintf(p.b, "%s}\n", p.indent)
+}
+
+// plantUMLText escapes line breaks; double quotes become single quotes.
+func plantUMLText(s string) string {
+	return strings.NewReplacer("\n", `\n`, `"`, "'").Replace(s)
+}
+
+// plantUMLColor returns a color name or hex value without the leading #.
+func plantUMLColor(c string) string {
+	return strings.TrimPrefix(c, "#")
+}
@@ -0,0 +1,75 @@
+package dot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWritePlantUML(t *testing.T) {
+	g := NewGraph(Directed)
+	g.Attr("rankdir", "LR")
+	g.Attr("label", "Flow")
+	a := g.Node(WithLabel("start"), func(a *AttributesMap) {
+		a.Attr("shape", "circle")
+		a.Attr("fontname", "Arial")
+	})
+	sub := g.NewSubgraph()
+	sub.Attr("label", "Work")
+	sub.Attr("bgcolor", "#eeeeee")
+	b := sub.Node(WithLabel("run\nfast"), func(a *AttributesMap) {
+		a.Attr("shape", "cylinder")
+		a.Attr("style", "filled,dashed")
+		a.Attr("fillcolor", "yellow")
+		a.Attr("URL", "https://example.com")
+	})
+	c := sub.Node(WithLabel("end"), func(a *AttributesMap) { a.Attr("shape", "box") })
+	g.Edge(a, b, WithLabel("go"), func(a *AttributesMap) {
+		a.Attr("style", "dotted")
+		a.Attr("color", "red")
+	})
+	sub.Edge(b, c, func(a *AttributesMap) { a.Attr("dir", "both") })
+
+	buf := new(bytes.Buffer)
+	report, err := g.WritePlantUML(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `@startuml
+title Flow
+left to right direction
+rectangle "Work" as cluster_2 #eeeeee {
+  rectangle "end" as n4
+  database "run\nfast" as n3 [[https://example.com]] #yellow;line.dashed
+  n3 <--> n4
+}
+circle "start" as n1
+n1 -[#red,dotted]-> n3 : go
+@enduml
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := report.String(), "node n1: fontname=Arial\n"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestWritePlantUMLContainerIDs(t *testing.T) {
+	g := NewGraph(Directed)
+	for _, id := range []string{"a.b", "a_b", "n2"} {
+		sub := g.NewSubgraph()
+		delete(g.subgraphs, sub.id)
+		sub.id, g.subgraphs[id] = id, sub
+		sub.Node()
+	}
+	buf := new(bytes.Buffer)
+	if _, err := g.WritePlantUML(buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`as a_b_2 {`, `as a_b {`, `as n2_2 {`, "as n2\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("got [%v] want [%v]", buf.String(), want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package dot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StronglyConnectedComponents returns the strongly connected components of
+// the graph (Tarjan's algorithm), including the nodes in subgraphs.
+// Every node belongs to exactly one component; nodes within a component and
+// components themselves are ordered by creation.
+func (g *Graph) StronglyConnectedComponents() [][]*Node {
+	adj := newAdjacency(g)
+	comps := adj.components()
+	res := make([][]*Node, len(comps))
+	for i, each := range comps {
+		res[i] = adj.nodesAt(each)
+	}
+	return res
+}
+
+// ClusterComponents returns a copy of the graph where each strongly connected
+// component with more than one node is moved into its own new cluster.
+// Eventually specify optional cluster attributes using the `withAttrs` functions.
+func (g *Graph) ClusterComponents(withAttrs ...func(*AttributesMap)) *Graph {
+	adj := newAdjacency(g)
+	groups := [][]*Node{}
+	for _, comp := range adj.components() {
+		if len(comp) > 1 {
+			groups = append(groups, adj.nodesAt(comp))
+		}
+	}
+	return g.regroup(groups, withAttrs...)
+}
+
+// Condensation returns the condensation of the graph: a new, flat DAG with
+// one node for each strongly connected component. Single node components
+// keep the original node identifier and attributes; larger ones become a
+// node identified by `scc_<n>` labeled with the labels of its members.
+// Parallel edges between components are merged; an edge keeps its
+// attributes only if it is the single edge between its two components.
+func (g *Graph) Condensation() *Graph {
+	adj := newAdjacency(g)
+	comps := adj.components()
+
+	c := NewGraph(GraphTypeOption{g.graphType})
+	c.id = g.id
+	c.AttributesMap = g.AttributesMap.clone()
+	c.nodeAttrs = g.nodeAttrs.clone()
+
+	owner := make([]int, len(adj.nodes))
+	nodes := make([]*Node, len(comps))
+	for i, comp := range comps {
+		for _, u := range comp {
+			owner[u] = i
+		}
+		if len(comp) == 1 {
+			n := adj.nodes[comp[0]]
+			nodes[i] = c.NodeWithID(n.id, withAttributesOf(n.AttributesMap))
+			continue
+		}
+		labels := make([]string, len(comp))
+		for j, u := range comp {
+			labels[j] = nodeCaption(adj.nodes[u])
+		}
+		nodes[i] = c.NodeWithID(fmt.Sprintf("scc_%d", i+1), WithLabel(strings.Join(labels, "\n")))
+	}
+
+	type pair struct{ from, to int }
+	merged := map[pair][]int{}
+	pairs := []pair{}
+	for e := range adj.edges {
+		p := pair{owner[adj.from[e]], owner[adj.to[e]]}
+		if p.from == p.to {
+			continue
+		}
+		if _, ok := merged[p]; !ok {
+			pairs = append(pairs, p)
+		}
+		merged[p] = append(merged[p], e)
+	}
+	for _, p := range pairs {
+		if edges := merged[p]; len(edges) == 1 {
+			c.Edge(nodes[p.from], nodes[p.to], withAttributesOf(adj.edges[edges[0]].AttributesMap))
+			continue
+		}
+		c.Edge(nodes[p.from], nodes[p.to])
+	}
+	return c
+}
+
+// components computes the strongly connected components with Tarjan's algorithm.
+func (adj *adjacency) components() [][]int {
+	index := make([]int, len(adj.nodes))
+	low := make([]int, len(adj.nodes))
+	onStack := make([]bool, len(adj.nodes))
+	for i := range index {
+		index[i] = -1
+	}
+	stack := []int{}
+	next := 0
+	comps := [][]int{}
+
+	var connect func(u int)
+	connect = func(u int) {
+		index[u], low[u] = next, next
+		next++
+		stack = append(stack, u)
+		onStack[u] = true
+
+		for _, v := range adj.successors(u) {
+			if index[v] < 0 {
+				connect(v)
+				if low[v] < low[u] {
+					low[u] = low[v]
+				}
+			} else if onStack[v] && index[v] < low[u] {
+				low[u] = index[v]
+			}
+		}
+
+		if low[u] == index[u] {
+			comp := []int{}
+			for {
+				v := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[v] = false
+				comp = append(comp, v)
+				if v == u {
+					break
+				}
+			}
+			sort.Ints(comp)
+			comps = append(comps, comp)
+		}
+	}
+
+	for u := range adj.nodes {
+		if index[u] < 0 {
+			connect(u)
+		}
+	}
+	sort.Slice(comps, func(i, j int) bool {
+		return comps[i][0] < comps[j][0]
+	})
+	return comps
+}
+
+// regroup returns a copy of the graph where each group of nodes is moved
+// into a new cluster of the top-level graph.
+func (g *Graph) regroup(groups [][]*Node, withAttrs ...func(*AttributesMap)) *Graph {
+	c := g.Clone()
+	for _, group := range groups {
+		sub := c.NewSubgraph()
+		for _, op := range withAttrs {
+			op(&sub.AttributesMap)
+		}
+		for _, n := range group {
+			c.moveNode(n.seq, sub)
+		}
+	}
+	return c
+}
+
+// moveNode moves the node with the given sequence number into dst,
+// which must belong to the same graph tree.
+func (g *Graph) moveNode(seq int, dst *Graph) {
+	g.Root().walkGraphs(func(each *Graph) {
+		for id, n := range each.nodes {
+			if n.seq != seq {
+				continue
+			}
+			delete(each.nodes, id)
+			n.graph = dst
+			dst.nodes[id] = n
+		}
+		for _, edges := range each.edgesFrom {
+			for _, e := range edges {
+				if e.from.seq == seq {
+					e.from.graph = dst
+				}
+				if e.to.seq == seq {
+					e.to.graph = dst
+				}
+			}
+		}
+	})
+}
+
+// nodeCaption returns the textual label of the node, or its identifier.
+func nodeCaption(n *Node) string {
+	if label, ok := n.Value("label").(string); ok && len(label) > 0 {
+		return label
+	}
+	return n.id
+}
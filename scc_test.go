@@ -0,0 +1,68 @@
+package dot
+
+import "testing"
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b, c, d := g.NodeWithID("a"), g.NodeWithID("b"), g.NodeWithID("c"), g.NodeWithID("d")
+	g.Edge(a, b)
+	g.Edge(b, a)
+	g.Edge(b, c)
+	g.Edge(a, c)
+	g.Edge(c, d)
+	g.Edge(d, c)
+	comps := g.StronglyConnectedComponents()
+	if got, want := len(comps), 2; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := nodeIDs(comps[0]), "a,b"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := nodeIDs(comps[1]), "c,d"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestClusterComponents(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b, c, d := g.NodeWithID("a"), g.NodeWithID("b"), g.NodeWithID("c"), g.NodeWithID("d")
+	g.Edge(a, b)
+	g.Edge(b, a)
+	g.Edge(c, d)
+	g.Edge(d, c)
+	g.NodeWithID("alone")
+	clustered := g.ClusterComponents()
+	if got, want := len(clustered.subgraphs), 2; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(clustered.nodes), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(g.nodes), 5; got != want {
+		t.Errorf("original graph modified: got [%v] want [%v]", got, want)
+	}
+}
+
+func TestCondensation(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b, c, d := g.NodeWithID("a"), g.NodeWithID("b"), g.NodeWithID("c"), g.NodeWithID("d")
+	g.Edge(a, b)
+	g.Edge(b, a)
+	g.Edge(b, c)
+	g.Edge(c, d)
+	g.Edge(d, c)
+	condensed := g.Condensation()
+	first, second := condensed.FindNodeByID("scc_1"), condensed.FindNodeByID("scc_2")
+	if first == nil || second == nil {
+		t.Fatalf("condensed nodes missing in [%v]", condensed)
+	}
+	if got, want := first.Value("label"), "a\nb"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(condensed.FindEdges(*first, *second)), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if _, err := condensed.TopologicalSort(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,586 @@
+package dot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Selection is the result of a selector query.
+type Selection struct {
+	Nodes []*Node
+	Edges []*Edge
+	// Subgraphs holds the matching subgraphs and, for `graph`, the top-level graph.
+	Subgraphs []*Graph
+}
+
+// Len returns the number of selected elements.
+func (s *Selection) Len() int {
+	return len(s.Nodes) + len(s.Edges) + len(s.Subgraphs)
+}
+
+// Attr sets the value for an attribute on all the selected elements.
+func (s *Selection) Attr(label string, value interface{}) *Selection {
+	return s.Apply(func(a *AttributesMap) { a.Attr(label, value) })
+}
+
+// Apply calls the `withAttrs` functions on the attributes of all the selected elements.
+func (s *Selection) Apply(withAttrs ...func(*AttributesMap)) *Selection {
+	for _, op := range withAttrs {
+		for _, n := range s.Nodes {
+			op(n.Attrs())
+		}
+		for _, e := range s.Edges {
+			op(e.Attrs())
+		}
+		for _, g := range s.Subgraphs {
+			op(&g.AttributesMap)
+		}
+	}
+	return s
+}
+
+// Select returns the nodes, edges and subgraphs matching the query, in
+// creation order. The query is a comma separated list of selectors like:
+//
+//	node[shape=box][label^="svc-"]
+//	edge[color=red]
+//	cluster#cluster_3 > node
+//	node:out-degree>5
+//
+// A selector is a sequence of compounds joined by `>` (child) or by spaces
+// (descendant). A compound starts with an optional kind: node, edge,
+// subgraph, cluster (a subgraph whose identifier starts with "cluster"),
+// graph (the top-level graph) or `*`, followed by any number of:
+//
+//	#id          the element identifier
+//	.name        a word of the `class` attribute
+//	[attr]       the attribute is set
+//	[attr=v]     equals; also != ^= (prefix) $= (suffix) *= (contains) ~= (word)
+//	:in-degree>N :out-degree>N :degree>N, with > >= < <= = !=, for nodes
+//
+// Values may be quoted. A node belongs to the subgraph it was created in,
+// an edge to the innermost subgraph holding both its nodes.
+func (g *Graph) Select(query string) (*Selection, error) {
+	sel, err := parseSelectors(query)
+	if err != nil {
+		return nil, err
+	}
+	ctx := newSelectContext(g)
+	res := &Selection{}
+	for _, el := range ctx.elements() {
+		if !sel.matches(el, ctx) {
+			continue
+		}
+		switch {
+		case el.node != nil:
+			res.Nodes = append(res.Nodes, el.node)
+		case el.edge != nil:
+			res.Edges = append(res.Edges, el.edge)
+		default:
+			res.Subgraphs = append(res.Subgraphs, el.graph)
+		}
+	}
+	return res, nil
+}
+
+// element kinds
+const (
+	kindNode     = "node"
+	kindEdge     = "edge"
+	kindSubgraph = "subgraph"
+	kindCluster  = "cluster"
+	kindGraph    = "graph"
+	kindAny      = "*"
+)
+
+// selectable is a node, an edge or a (sub)graph seen by the selectors.
+type selectable struct {
+	kind   string
+	id     string
+	attrs  *AttributesMap
+	parent *Graph
+	node   *Node
+	edge   *Edge
+	graph  *Graph
+}
+
+func nodeSelectable(n *Node) selectable {
+	return selectable{kind: kindNode, id: n.id, attrs: n.Attrs(), parent: n.graph, node: n}
+}
+
+func edgeSelectable(e *Edge) selectable {
+	return selectable{kind: kindEdge, attrs: e.Attrs(), parent: innermostCommonGraph(e.from.graph, e.to.graph), edge: e}
+}
+
+func graphSelectable(g *Graph) selectable {
+	kind := kindGraph
+	if g.parent != nil {
+		kind = kindSubgraph
+	}
+	return selectable{kind: kind, id: g.id, attrs: &g.AttributesMap, parent: g.parent, graph: g}
+}
+
+// selectContext provides the data selectors need about the whole graph.
+type selectContext struct {
+	graph     *Graph
+	inDegree  map[string]int
+	outDegree map[string]int
+}
+
+func newSelectContext(g *Graph) *selectContext {
+	ctx := &selectContext{graph: g, inDegree: map[string]int{}, outDegree: map[string]int{}}
+	for _, e := range g.allEdges() {
+		ctx.outDegree[e.from.id]++
+		ctx.inDegree[e.to.id]++
+	}
+	return ctx
+}
+
+// elements returns the graphs, nodes and edges in creation order.
+func (ctx *selectContext) elements() []selectable {
+	res := []selectable{}
+	ctx.graph.walkGraphs(func(each *Graph) {
+		res = append(res, graphSelectable(each))
+	})
+	for _, n := range ctx.graph.allNodes() {
+		res = append(res, nodeSelectable(n))
+	}
+	for _, e := range ctx.graph.allEdges() {
+		res = append(res, edgeSelectable(e))
+	}
+	return res
+}
+
+// selectorList is a parsed comma separated list of selectors.
+type selectorList []complexSelector
+
+func (l selectorList) matches(el selectable, ctx *selectContext) bool {
+	for _, each := range l {
+		if each.matches(el, ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+// complexSelector is a sequence of compounds; child[i] tells whether
+// compounds[i] must be the parent (not just an ancestor) of compounds[i+1].
+type complexSelector struct {
+	compounds []compound
+	child     []bool
+}
+
+// specificity counts identifiers, classes-attributes-pseudos and kinds.
+func (s complexSelector) specificity() [3]int {
+	var spec [3]int
+	for _, c := range s.compounds {
+		if len(c.id) > 0 {
+			spec[0]++
+		}
+		spec[1] += len(c.classes) + len(c.attrs) + len(c.pseudos)
+		if len(c.kind) > 0 && c.kind != kindAny {
+			spec[2]++
+		}
+	}
+	return spec
+}
+
+func (s complexSelector) matches(el selectable, ctx *selectContext) bool {
+	last := len(s.compounds) - 1
+	if !s.compounds[last].matches(el, ctx) {
+		return false
+	}
+	return s.matchAncestors(last-1, el.parent, ctx)
+}
+
+// matchAncestors matches compounds[0..i] against the graph g and its parents.
+func (s complexSelector) matchAncestors(i int, g *Graph, ctx *selectContext) bool {
+	if i < 0 {
+		return true
+	}
+	for ; g != nil; g = g.parent {
+		if s.compounds[i].matches(graphSelectable(g), ctx) && s.matchAncestors(i-1, g.parent, ctx) {
+			return true
+		}
+		if s.child[i] {
+			return false
+		}
+	}
+	return false
+}
+
+// compound is a simple selector like `node#id.class[attr=value]:degree>2`.
+type compound struct {
+	kind    string
+	id      string
+	classes []string
+	attrs   []attrFilter
+	pseudos []pseudoFilter
+}
+
+func (c compound) matches(el selectable, ctx *selectContext) bool {
+	switch c.kind {
+	case "", kindAny:
+	case kindCluster:
+		if el.kind != kindSubgraph || !strings.HasPrefix(el.id, "cluster") {
+			return false
+		}
+	default:
+		if c.kind != el.kind {
+			return false
+		}
+	}
+	if len(c.id) > 0 && c.id != el.id {
+		return false
+	}
+	for _, class := range c.classes {
+		if !containsWord(attrString(el.attrs.Value("class")), class) {
+			return false
+		}
+	}
+	for _, each := range c.attrs {
+		if !each.matches(el.attrs) {
+			return false
+		}
+	}
+	for _, each := range c.pseudos {
+		if el.node == nil || !each.matches(el.node.id, ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+type attrFilter struct {
+	name  string
+	op    string
+	value string
+}
+
+func (f attrFilter) matches(a *AttributesMap) bool {
+	v := a.Value(f.name)
+	if v == nil {
+		return f.op == "!="
+	}
+	s := attrString(v)
+	switch f.op {
+	case "":
+		return true
+	case "=":
+		return s == f.value
+	case "!=":
+		return s != f.value
+	case "^=":
+		return strings.HasPrefix(s, f.value)
+	case "$=":
+		return strings.HasSuffix(s, f.value)
+	case "*=":
+		return strings.Contains(s, f.value)
+	case "~=":
+		return containsWord(s, f.value)
+	}
+	return false
+}
+
+type pseudoFilter struct {
+	name  string
+	op    string
+	value int
+}
+
+func (f pseudoFilter) matches(id string, ctx *selectContext) bool {
+	var degree int
+	switch f.name {
+	case "in-degree":
+		degree = ctx.inDegree[id]
+	case "out-degree":
+		degree = ctx.outDegree[id]
+	default:
+		degree = ctx.inDegree[id] + ctx.outDegree[id]
+	}
+	switch f.op {
+	case ">":
+		return degree > f.value
+	case ">=":
+		return degree >= f.value
+	case "<":
+		return degree < f.value
+	case "<=":
+		return degree <= f.value
+	case "=":
+		return degree == f.value
+	case "!=":
+		return degree != f.value
+	}
+	return false
+}
+
+// attrString returns the textual value of an attribute.
+func attrString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func containsWord(s, word string) bool {
+	for _, each := range strings.Fields(s) {
+		if each == word {
+			return true
+		}
+	}
+	return false
+}
+
+// innermostCommonGraph returns the deepest graph containing both graphs.
+func innermostCommonGraph(a, b *Graph) *Graph {
+	ancestors := map[*Graph]bool{}
+	for g := a; g != nil; g = g.parent {
+		ancestors[g] = true
+	}
+	for g := b; g != nil; g = g.parent {
+		if ancestors[g] {
+			return g
+		}
+	}
+	return a.Root()
+}
+
+// selectorParser is a hand written recursive descent parser for selectors.
+type selectorParser struct {
+	src []rune
+	pos int
+}
+
+func parseSelectors(query string) (selectorList, error) {
+	p := &selectorParser{src: []rune(query)}
+	list := selectorList{}
+	for {
+		sel, err := p.complex()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, sel)
+		p.skipSpaces()
+		if p.done() {
+			return list, nil
+		}
+		if !p.accept(',') {
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+	}
+}
+
+func (p *selectorParser) complex() (complexSelector, error) {
+	sel := complexSelector{}
+	p.skipSpaces()
+	for {
+		c, err := p.compound()
+		if err != nil {
+			return sel, err
+		}
+		sel.compounds = append(sel.compounds, c)
+
+		spaced := p.skipSpaces()
+		child := p.accept('>')
+		if child {
+			p.skipSpaces()
+		} else if !spaced || p.done() || p.peek() == ',' {
+			return sel, nil
+		}
+		sel.child = append(sel.child, child)
+	}
+}
+
+func (p *selectorParser) compound() (compound, error) {
+	c := compound{}
+	start := p.pos
+	if p.accept('*') {
+		c.kind = kindAny
+	} else if !p.done() && unicode.IsLetter(p.peek()) {
+		c.kind = p.ident()
+		switch c.kind {
+		case kindNode, kindEdge, kindSubgraph, kindCluster, kindGraph:
+		default:
+			return c, p.errorf("unknown element kind %q", c.kind)
+		}
+	}
+
+	for !p.done() {
+		switch p.peek() {
+		case '#':
+			p.pos++
+			if c.id = p.ident(); len(c.id) == 0 {
+				return c, p.errorf("missing identifier after #")
+			}
+		case '.':
+			p.pos++
+			class := p.ident()
+			if len(class) == 0 {
+				return c, p.errorf("missing class name after .")
+			}
+			c.classes = append(c.classes, class)
+		case '[':
+			p.pos++
+			f, err := p.attrFilter()
+			if err != nil {
+				return c, err
+			}
+			c.attrs = append(c.attrs, f)
+		case ':':
+			p.pos++
+			f, err := p.pseudoFilter()
+			if err != nil {
+				return c, err
+			}
+			c.pseudos = append(c.pseudos, f)
+		default:
+			if p.pos == start {
+				return c, p.errorf("unexpected %q", p.peek())
+			}
+			return c, nil
+		}
+	}
+	if p.pos == start {
+		return c, p.errorf("missing selector")
+	}
+	return c, nil
+}
+
+func (p *selectorParser) attrFilter() (attrFilter, error) {
+	f := attrFilter{}
+	p.skipSpaces()
+	if f.name = p.ident(); len(f.name) == 0 {
+		return f, p.errorf("missing attribute name")
+	}
+	p.skipSpaces()
+	if p.accept(']') {
+		return f, nil
+	}
+	for _, op := range []string{"!=", "^=", "$=", "*=", "~=", "="} {
+		if p.acceptString(op) {
+			f.op = op
+			break
+		}
+	}
+	if len(f.op) == 0 {
+		return f, p.errorf("missing attribute operator")
+	}
+	p.skipSpaces()
+	value, err := p.value(']')
+	if err != nil {
+		return f, err
+	}
+	f.value = value
+	p.skipSpaces()
+	if !p.accept(']') {
+		return f, p.errorf("missing ]")
+	}
+	return f, nil
+}
+
+func (p *selectorParser) pseudoFilter() (pseudoFilter, error) {
+	f := pseudoFilter{name: p.ident()}
+	switch f.name {
+	case "in-degree", "out-degree", "degree":
+	default:
+		return f, p.errorf("unknown pseudo selector %q", f.name)
+	}
+	for _, op := range []string{">=", "<=", "!=", ">", "<", "="} {
+		if p.acceptString(op) {
+			f.op = op
+			break
+		}
+	}
+	if len(f.op) == 0 {
+		return f, p.errorf("missing comparison after :%s", f.name)
+	}
+	start := p.pos
+	for !p.done() && unicode.IsDigit(p.peek()) {
+		p.pos++
+	}
+	n, err := strconv.Atoi(string(p.src[start:p.pos]))
+	if err != nil {
+		return f, p.errorf("invalid number after :%s%s", f.name, f.op)
+	}
+	f.value = n
+	return f, nil
+}
+
+// value reads a quoted string or a bare value up to the terminator.
+func (p *selectorParser) value(terminator rune) (string, error) {
+	if p.done() {
+		return "", p.errorf("missing value")
+	}
+	if quote := p.peek(); quote == '"' || quote == '\'' {
+		p.pos++
+		b := new(strings.Builder)
+		for !p.done() && p.peek() != quote {
+			if p.peek() == '\\' && p.pos+1 < len(p.src) {
+				p.pos++
+			}
+			b.WriteRune(p.peek())
+			p.pos++
+		}
+		if !p.accept(quote) {
+			return "", p.errorf("unterminated string")
+		}
+		return b.String(), nil
+	}
+	start := p.pos
+	for !p.done() && p.peek() != terminator {
+		p.pos++
+	}
+	return strings.TrimSpace(string(p.src[start:p.pos])), nil
+}
+
+func (p *selectorParser) ident() string {
+	start := p.pos
+	for !p.done() {
+		r := p.peek()
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			break
+		}
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+func (p *selectorParser) skipSpaces() bool {
+	start := p.pos
+	for !p.done() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *selectorParser) accept(r rune) bool {
+	if !p.done() && p.peek() == r {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *selectorParser) acceptString(s string) bool {
+	rs := []rune(s)
+	if p.pos+len(rs) > len(p.src) || string(p.src[p.pos:p.pos+len(rs)]) != s {
+		return false
+	}
+	p.pos += len(rs)
+	return true
+}
+
+func (p *selectorParser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *selectorParser) peek() rune {
+	return p.src[p.pos]
+}
+
+func (p *selectorParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("selector: "+format+" at offset %d", append(args, p.pos)...)
+}
@@ -0,0 +1,79 @@
+package dot
+
+import "testing"
+
+func TestSelect(t *testing.T) {
+	g := NewGraph(Directed)
+	gw := g.NodeWithID("gateway", func(a *AttributesMap) { a.Attr("shape", "box").Attr("class", "edge-tier public") })
+	cluster := g.NewSubgraph()
+	cluster.Label("services")
+	orders := cluster.NodeWithID("orders", WithLabel("svc-orders"), func(a *AttributesMap) { a.Attr("shape", "box") })
+	users := cluster.NodeWithID("users", WithLabel("svc-users"))
+	db := g.NodeWithID("db", func(a *AttributesMap) { a.Attr("shape", "cylinder") })
+	g.Edge(gw, orders).Attr("color", "red")
+	g.Edge(gw, users)
+	g.Edge(orders, users)
+	g.Edge(orders, db)
+	g.Edge(users, db)
+	for _, each := range []struct {
+		query string
+		nodes string
+		edges int
+		subs  int
+	}{
+		{query: `node[shape=box]`, nodes: "gateway,orders"},
+		{query: `node[shape=box][label^="svc-"]`, nodes: "orders"},
+		{query: `node[label$=users], #db`, nodes: "users,db"},
+		{query: `node[shape!=box]`, nodes: "users,db"},
+		{query: `.public`, nodes: "gateway"},
+		{query: `node[class~=edge-tier]`, nodes: "gateway"},
+		{query: `edge[color=red]`, edges: 1},
+		{query: `edge`, edges: 5},
+		{query: `cluster > node`, nodes: "orders,users"},
+		{query: `graph > node`, nodes: "gateway,db"},
+		{query: `cluster edge`, edges: 1},
+		{query: `cluster[label=services]`, subs: 1},
+		{query: `cluster#cluster_2 > node`, nodes: "orders,users"},
+		{query: `node:out-degree>1`, nodes: "gateway,orders"},
+		{query: `node:in-degree=2`, nodes: "users,db"},
+		{query: `*:degree>=3`, nodes: "orders,users"},
+	} {
+		sel, err := g.Select(each.query)
+		if err != nil {
+			t.Errorf("%s: %v", each.query, err)
+			continue
+		}
+		if got, want := nodeIDs(sel.Nodes), each.nodes; got != want {
+			t.Errorf("%s: got nodes [%v] want [%v]", each.query, got, want)
+		}
+		if got, want := len(sel.Edges), each.edges; got != want {
+			t.Errorf("%s: got edges [%v] want [%v]", each.query, got, want)
+		}
+		if got, want := len(sel.Subgraphs), each.subs; got != want {
+			t.Errorf("%s: got subgraphs [%v] want [%v]", each.query, got, want)
+		}
+	}
+}
+
+func TestSelectionAttr(t *testing.T) {
+	g := NewGraph(Directed)
+	orders := g.NodeWithID("orders", func(a *AttributesMap) { a.Attr("shape", "box") })
+	db := g.NodeWithID("db", func(a *AttributesMap) { a.Attr("shape", "cylinder") })
+	sel, _ := g.Select(`node[shape=box]`)
+	sel.Attr("color", "blue")
+	if got, want := orders.Value("color"), "blue"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got := db.Value("color"); got != nil {
+		t.Errorf("got [%v] want [%v]", got, nil)
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	g := NewGraph(Directed)
+	for _, query := range []string{``, `box`, `node[`, `node[shape`, `node:weight>2`, `node:degree>`, `node,`, `node[label="x]`} {
+		if _, err := g.Select(query); err == nil {
+			t.Errorf("%s: expected error", query)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package dot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Stylesheet is a list of rules setting attributes on the elements matching
+// their selectors (see Select). A stylesheet in use by a graph is applied
+// each time the graph is written, without changing its attributes.
+//
+// When several rules set the same attribute, the rule with the most specific
+// selector wins: identifiers count more than classes, attributes and degrees,
+// which count more than kinds. Among rules with the same specificity the last
+// one wins. Attributes set on the element itself always win.
+type Stylesheet struct {
+	rules []styleRule
+}
+
+type styleRule struct {
+	selector complexSelector
+	order    int
+	attrs    AttributesMap
+}
+
+// NewStylesheet returns an empty stylesheet.
+func NewStylesheet() *Stylesheet {
+	return &Stylesheet{}
+}
+
+// ParseStylesheet reads a stylesheet made of rules like:
+//
+//	/* all nodes */
+//	node { shape: box; fontname: "Helvetica" }
+//	node.db, node[kind=storage] { shape: cylinder }
+//	cluster#cluster_3 node { style: filled; fillcolor: "#eeeeee" }
+//	edge[color=red] { penwidth: 2 }
+//	graph { rankdir: LR }
+//
+// Values may be quoted; values enclosed in <> are HTML.
+func ParseStylesheet(src string) (*Stylesheet, error) {
+	s := NewStylesheet()
+	p := &stylesheetParser{src: []rune(stripComments(src))}
+	for {
+		p.skipSpaces()
+		if p.done() {
+			return s, nil
+		}
+		selector, ok := p.until('{')
+		if !ok {
+			return nil, p.errorf("missing {")
+		}
+		body, ok := p.until('}')
+		if !ok {
+			return nil, p.errorf("missing }")
+		}
+		decls, err := parseDeclarations(body)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if err := s.Rule(selector, decls); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Rule appends a rule applying the `withAttrs` functions to the elements
+// matching the selector.
+func (s *Stylesheet) Rule(selector string, withAttrs ...func(*AttributesMap)) error {
+	list, err := parseSelectors(selector)
+	if err != nil {
+		return err
+	}
+	attrs := AttributesMap{attributes: map[string]interface{}{}}
+	for _, op := range withAttrs {
+		op(&attrs)
+	}
+	order := len(s.rules)
+	for _, each := range list {
+		s.rules = append(s.rules, styleRule{selector: each, order: order, attrs: attrs})
+	}
+	return nil
+}
+
+// UseStylesheet sets the stylesheet applied when writing the graph;
+// nil removes it. Subgraphs share the stylesheet of the top-level graph.
+func (g *Graph) UseStylesheet(s *Stylesheet) *Graph {
+	g.Root().stylesheet = s
+	return g
+}
+
+// styler computes the attributes to write for each element.
+type styler struct {
+	sheet *Stylesheet
+	ctx   *selectContext
+}
+
+func newStyler(root *Graph) *styler {
+	st := &styler{sheet: root.stylesheet}
+	if st.sheet != nil && len(st.sheet.rules) > 0 {
+		st.ctx = newSelectContext(root)
+	}
+	return st
+}
+
+// attributes returns the element attributes merged with the matching rules.
+func (st *styler) attributes(el selectable) map[string]interface{} {
+	if st.ctx == nil {
+		return el.attrs.attributes
+	}
+	matched := []styleRule{}
+	for _, rule := range st.sheet.rules {
+		if rule.selector.matches(el, st.ctx) {
+			matched = append(matched, rule)
+		}
+	}
+	if len(matched) == 0 {
+		return el.attrs.attributes
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := matched[i].selector.specificity(), matched[j].selector.specificity()
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return matched[i].order < matched[j].order
+	})
+
+	merged := map[string]interface{}{}
+	for _, rule := range matched {
+		for k, v := range rule.attrs.attributes {
+			merged[k] = v
+		}
+	}
+	for k, v := range el.attrs.attributes {
+		merged[k] = v
+	}
+	return merged
+}
+
+// parseDeclarations reads `name: value; ...` pairs.
+func parseDeclarations(body string) (func(*AttributesMap), error) {
+	p := &stylesheetParser{src: []rune(body)}
+	names, values := []string{}, []interface{}{}
+	for {
+		p.skipSpaces()
+		if p.done() {
+			break
+		}
+		decl, _ := p.until(';')
+		if len(strings.TrimSpace(decl)) == 0 {
+			continue
+		}
+		colon := strings.Index(decl, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("missing : in %q", strings.TrimSpace(decl))
+		}
+		name := strings.TrimSpace(decl[:colon])
+		if len(name) == 0 {
+			return nil, fmt.Errorf("missing attribute name in %q", strings.TrimSpace(decl))
+		}
+		value, err := declarationValue(strings.TrimSpace(decl[colon+1:]))
+		if err != nil {
+			return nil, err
+		}
+		names, values = append(names, name), append(values, value)
+	}
+	return func(a *AttributesMap) {
+		for i, name := range names {
+			a.Attr(name, values[i])
+		}
+	}, nil
+}
+
+func declarationValue(raw string) (interface{}, error) {
+	switch {
+	case len(raw) == 0:
+		return nil, fmt.Errorf("missing value")
+	case strings.HasPrefix(raw, `"`):
+		return strconv.Unquote(raw)
+	case strings.HasPrefix(raw, `'`) && strings.HasSuffix(raw, `'`) && len(raw) > 1:
+		return raw[1 : len(raw)-1], nil
+	case strings.HasPrefix(raw, "<") && strings.HasSuffix(raw, ">"):
+		return HTML(raw[1 : len(raw)-1]), nil
+	}
+	return raw, nil
+}
+
+// stripComments removes /* */ comments outside quoted strings.
+func stripComments(src string) string {
+	b := new(strings.Builder)
+	var quote rune
+	rs := []rune(src)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case quote != 0:
+			if r == '\\' && i+1 < len(rs) {
+				b.WriteRune(r)
+				i++
+				r = rs[i]
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			j := i + 2
+			for j+1 < len(rs) && (rs[j] != '*' || rs[j+1] != '/') {
+				j++
+			}
+			if j+1 >= len(rs) {
+				return b.String()
+			}
+			i = j + 1
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type stylesheetParser struct {
+	src []rune
+	pos int
+}
+
+// until returns the text up to the terminator, outside quoted strings, and
+// consumes the terminator. It reports false if the terminator is missing.
+func (p *stylesheetParser) until(terminator rune) (string, bool) {
+	start := p.pos
+	var quote rune
+	for ; p.pos < len(p.src); p.pos++ {
+		r := p.src[p.pos]
+		switch {
+		case quote != 0:
+			if r == '\\' {
+				p.pos++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == terminator:
+			p.pos++
+			return string(p.src[start : p.pos-1]), true
+		}
+	}
+	return string(p.src[start:]), false
+}
+
+func (p *stylesheetParser) skipSpaces() {
+	for p.pos < len(p.src) && strings.ContainsRune(" \t\r\n", p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *stylesheetParser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *stylesheetParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("stylesheet: "+format+" at offset %d", append(args, p.pos)...)
+}
@@ -0,0 +1,84 @@
+package dot
+
+import "testing"
+
+const testStylesheet = `
+/* defaults */
+node { shape: box; fontname: "Helvetica" }
+node.db, node[kind=storage] { shape: cylinder; }
+cluster#cluster_2 node { style: filled; fillcolor: "#eeeeee" }
+edge[color=red] { penwidth: 2 }
+graph { rankdir: LR }
+node#special { label: <<B>special</B>> }
+`
+
+func TestStylesheetAppliedAtWriteTime(t *testing.T) {
+	sheet, err := ParseStylesheet(testStylesheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewGraph(Directed)
+	api := g.Node(WithLabel("api"))
+	sub := g.NewSubgraph()
+	db := sub.Node(WithLabel("db"), func(a *AttributesMap) { a.Attr("class", "db") })
+	g.Edge(api, db).Attr("color", "red")
+	g.UseStylesheet(sheet)
+
+	want := `digraph  {rankdir="LR";` +
+		`subgraph cluster_2 {label="cluster_2";n3[class="db",fillcolor="#eeeeee",fontname="Helvetica",label="db",shape="cylinder",style="filled"];}` +
+		`n1[fontname="Helvetica",label="api",shape="box"];` +
+		`n1->n3[color="red",penwidth="2"];}`
+	if got := flatten(g.String()); got != want {
+		t.Errorf("got\n[%v] want\n[%v]", got, want)
+	}
+	if got := api.Value("shape"); got != nil {
+		t.Errorf("stylesheet must not change attributes: got [%v]", got)
+	}
+
+	g.UseStylesheet(nil)
+	if got, want := flatten(g.String()), `digraph  {subgraph cluster_2 {label="cluster_2";n3[class="db",label="db"];}n1[label="api"];n1->n3[color="red"];}`; got != want {
+		t.Errorf("got\n[%v] want\n[%v]", got, want)
+	}
+}
+
+func TestStylesheetSpecificity(t *testing.T) {
+	sheet := NewStylesheet()
+	sheet.Rule("node#a", func(a *AttributesMap) { a.Attr("color", "red") })
+	sheet.Rule("node", func(a *AttributesMap) { a.Attr("color", "blue") })
+	sheet.Rule("node[shape]", func(a *AttributesMap) { a.Attr("color", "green") })
+	sheet.Rule("node[shape]", func(a *AttributesMap) { a.Attr("color", "black") })
+
+	g := NewGraph(Directed)
+	g.NodeWithID("a", func(a *AttributesMap) { a.Attr("shape", "box") })
+	g.UseStylesheet(sheet)
+	if got, want := flatten(g.String()), `digraph  {n1[color="red",label="a",shape="box"];}`; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	g.FindNodeByID("a").Attr("color", "pink")
+	if got, want := flatten(g.String()), `digraph  {n1[color="pink",label="a",shape="box"];}`; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestStylesheetHTMLValue(t *testing.T) {
+	sheet, err := ParseStylesheet(testStylesheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGraph(Directed)
+	g.NodeWithID("special").Delete("label")
+	g.UseStylesheet(sheet)
+	if got, want := flatten(g.String()), `digraph  {rankdir="LR";n1[fontname="Helvetica",label=<<B>special</B>>,shape="box"];}`; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestParseStylesheetErrors(t *testing.T) {
+	for _, src := range []string{`node`, `node { shape: box`, `node { shape }`, `box { shape: box }`, `node { : box }`} {
+		if _, err := ParseStylesheet(src); err == nil {
+			t.Errorf("%s: expected error", src)
+		}
+	}
+}
@@ -0,0 +1,519 @@
+package dot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// svgPadding is the space around the drawing, in points.
+const svgPadding = 4.0
+
+// svgPolygons holds the outline of the polygonal shapes, scaled to the node
+// size: x and y go from -1 to 1, y going up.
+var svgPolygons = map[string][]point{
+	"diamond":       regularPolygon(4, 0),
+	"triangle":      regularPolygon(3, 90),
+	"invtriangle":   regularPolygon(3, -90),
+	"pentagon":      regularPolygon(5, 90),
+	"hexagon":       regularPolygon(6, 0),
+	"septagon":      regularPolygon(7, 90),
+	"octagon":       regularPolygon(8, 22.5),
+	"parallelogram": {{-0.5, 1}, {1, 1}, {0.5, -1}, {-1, -1}},
+	"trapezium":     {{-1, -1}, {1, -1}, {0.5, 1}, {-0.5, 1}},
+	"invtrapezium":  {{-1, 1}, {1, 1}, {0.5, -1}, {-0.5, -1}},
+	"house":         {{-1, -1}, {1, -1}, {1, 0.3}, {0, 1}, {-1, 0.3}},
+	"invhouse":      {{-1, 1}, {1, 1}, {1, -0.3}, {0, -1}, {-1, -0.3}},
+	"star":          starPolygon(),
+	"note":          {{-1, -1}, {1, -1}, {1, 0.6}, {0.75, 1}, {-1, 1}},
+}
+
+// regularPolygon returns the vertices of a regular polygon, the first at
+// the given angle in degrees, stretched to fill the -1..1 square.
+func regularPolygon(sides int, start float64) []point {
+	res := make([]point, sides)
+	b := emptyBox()
+	for i := range res {
+		a := (start + 360*float64(i)/float64(sides)) * math.Pi / 180
+		res[i] = point{math.Cos(a), math.Sin(a)}
+		b.add(res[i])
+	}
+	for i, p := range res {
+		res[i] = point{2*(p.x-b.llx)/(b.urx-b.llx) - 1, 2*(p.y-b.lly)/(b.ury-b.lly) - 1}
+	}
+	return res
+}
+
+func starPolygon() []point {
+	res := make([]point, 10)
+	for i := range res {
+		a := math.Pi/2 + math.Pi*float64(i)/5
+		r := 1.0
+		if i%2 == 1 {
+			r = 0.4
+		}
+		res[i] = point{r * math.Cos(a), r * math.Sin(a)}
+	}
+	return res
+}
+
+// WriteSVG renders the graph as SVG, from the layout stored in its
+// attributes by LayeredLayout, ForceLayout or CircularLayout (or by the
+// Graphviz dot output format): the graph `bb`, the node `pos`, `width` and
+// `height`, the edge `pos` (a straight line when missing), the cluster `bb`
+// and the `lp` of the labels.
+//
+// Nodes are drawn with their shape, the common ones are supported, their
+// style (filled, rounded, dashed, dotted, bold, invis), colors and label;
+// text metrics are estimated from the font size and name. Edges get their
+// arrowheads and label, clusters a rectangle with their label. Elements
+// with `URL` become links and `tooltip` becomes their title. Attributes
+// set by the stylesheet in use, if any, are honored too.
+func (g *Graph) WriteSVG(w io.Writer) error {
+	root := g.Root()
+	bb, ok := parseFloats(root.Value("bb"))
+	if !ok || len(bb) != 4 {
+		return errors.New("the graph has no layout: bb is missing")
+	}
+	s := &svgWriter{b: new(strings.Builder), st: newStyler(root), bb: box{bb[0], bb[1], bb[2], bb[3]}}
+	adj := newAdjacency(root)
+	for _, n := range adj.nodes {
+		if pos, ok := parseFloats(effectiveNodeAttributes(n, s.st)["pos"]); !ok || len(pos) < 2 {
+			return fmt.Errorf("node %q has no layout: pos is missing or invalid", n.id)
+		}
+	}
+
+	width, height := s.bb.urx-s.bb.llx+2*svgPadding, s.bb.ury-s.bb.lly+2*svgPadding
+	fmt.Fprintf(s.b, "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n")
+	fmt.Fprintf(s.b, "<svg xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" width=\"%spt\" height=\"%spt\" viewBox=\"0 0 %s %s\">\n",
+		formatCoord(width), formatCoord(height), formatCoord(width), formatCoord(height))
+	s.graph(root, width, height)
+	for i, c := range clusterGraphs(root) {
+		s.cluster(i+1, c)
+	}
+	for i, e := range adj.edges {
+		s.edge(i+1, e)
+	}
+	for i, n := range adj.nodes {
+		s.node(i+1, n)
+	}
+	fmt.Fprintf(s.b, "</g>\n</svg>\n")
+
+	_, err := io.WriteString(w, s.b.String())
+	return err
+}
+
+type svgWriter struct {
+	b  *strings.Builder
+	st *styler
+	bb box
+}
+
+// at converts layout coordinates, y going up, to SVG coordinates.
+func (s *svgWriter) at(p point) point {
+	return point{p.x - s.bb.llx + svgPadding, s.bb.ury - p.y + svgPadding}
+}
+
+func (s *svgWriter) graph(g *Graph, width, height float64) {
+	attrs := s.st.attributes(graphSelectable(g))
+	fmt.Fprintf(s.b, "<g id=\"graph0\" class=\"graph\">\n")
+	if len(g.id) > 0 {
+		fmt.Fprintf(s.b, "<title>%s</title>\n", svgEscape(g.id))
+	}
+	background := "white"
+	if c := attrString(attrs["bgcolor"]); len(c) > 0 {
+		background = c
+	}
+	if background != "transparent" {
+		fmt.Fprintf(s.b, "<rect x=\"0\" y=\"0\" width=\"%s\" height=\"%s\"%s stroke=\"none\"/>\n", formatCoord(width), formatCoord(height), svgPaint("fill", background))
+	}
+	if lp, ok := parseFloats(attrs["lp"]); ok && len(lp) == 2 {
+		s.text(point{lp[0], lp[1]}, attrs)
+	}
+}
+
+func (s *svgWriter) cluster(index int, c *Graph) {
+	bb, ok := parseFloats(c.Value("bb"))
+	if !ok || len(bb) != 4 {
+		return
+	}
+	attrs := s.st.attributes(graphSelectable(c))
+	style := newExportStyle(attrs, map[string]bool{})
+	if style.invisible {
+		return
+	}
+	fill := "none"
+	if style.filled {
+		fill = firstNonEmpty(style.fill, "lightgrey")
+	} else if bg := attrString(attrs["bgcolor"]); len(bg) > 0 {
+		fill = bg
+	}
+	fmt.Fprintf(s.b, "<g id=\"clust%d\" class=\"cluster\">\n<title>%s</title>\n", index, svgEscape(c.id))
+	end := s.link(attrs)
+	ll, ur := s.at(point{bb[0], bb[1]}), s.at(point{bb[2], bb[3]})
+	s.rect(point{(ll.x + ur.x) / 2, (ll.y + ur.y) / 2}, ur.x-ll.x, ll.y-ur.y, style.rounded, fill, firstNonEmpty(style.stroke, "black"), style)
+	if lp, ok := parseFloats(attrs["lp"]); ok && len(lp) == 2 {
+		s.text(point{lp[0], lp[1]}, attrs)
+	}
+	fmt.Fprintf(s.b, "%s</g>\n", end)
+}
+
+func (s *svgWriter) node(index int, n *Node) {
+	attrs := effectiveNodeAttributes(n, s.st)
+	style := newExportStyle(attrs, map[string]bool{})
+	if style.invisible {
+		return
+	}
+	pos, _ := parseFloats(attrs["pos"])
+	p := point{pos[0], pos[1]}
+	c := s.at(p)
+	w, h := nodeSize(attrs)
+	if f, ok := numericValue(attrs["width"]); ok {
+		w = f * pointsPerInch
+	}
+	if f, ok := numericValue(attrs["height"]); ok {
+		h = f * pointsPerInch
+	}
+	shape := shapeOf(attrs)
+	stroke := firstNonEmpty(style.stroke, "black")
+	fill := "none"
+	if style.filled {
+		fill = firstNonEmpty(style.fill, "lightgrey")
+	}
+	if shape == "point" {
+		fill = firstNonEmpty(style.fill, stroke)
+	}
+
+	fmt.Fprintf(s.b, "<g id=\"node%d\" class=\"node\">\n<title>%s</title>\n", index, svgEscape(firstNonEmpty(attrString(attrs["tooltip"]), n.id)))
+	end := s.link(attrs)
+	paint := svgPaint("fill", fill) + svgStroke(stroke, style)
+	switch {
+	case roundShape(shape):
+		fmt.Fprintf(s.b, "<ellipse cx=\"%s\" cy=\"%s\" rx=\"%s\" ry=\"%s\"%s/>\n", formatCoord(c.x), formatCoord(c.y), formatCoord(w/2), formatCoord(h/2), paint)
+		if shape == "doublecircle" {
+			fmt.Fprintf(s.b, "<ellipse cx=\"%s\" cy=\"%s\" rx=\"%s\" ry=\"%s\" fill=\"none\"%s/>\n", formatCoord(c.x), formatCoord(c.y), formatCoord(w/2-4), formatCoord(h/2-4), svgStroke(stroke, style))
+		}
+	case shape == "cylinder":
+		ry := h / 10
+		top, bottom := c.y-h/2+ry, c.y+h/2-ry
+		fmt.Fprintf(s.b, "<path d=\"M%s,%s A%s,%s 0 0,0 %s,%s V%s A%s,%s 0 0,0 %s,%s V%s A%s,%s 0 0,1 %s,%s\"%s/>\n",
+			formatCoord(c.x+w/2), formatCoord(top), formatCoord(w/2), formatCoord(ry), formatCoord(c.x-w/2), formatCoord(top),
+			formatCoord(bottom), formatCoord(w/2), formatCoord(ry), formatCoord(c.x+w/2), formatCoord(bottom),
+			formatCoord(top), formatCoord(w/2), formatCoord(ry), formatCoord(c.x-w/2), formatCoord(top), paint)
+	case svgPolygons[shape] != nil:
+		points := []string{}
+		for _, p := range svgPolygons[shape] {
+			points = append(points, formatPoint(point{c.x + p.x*w/2, c.y - p.y*h/2}))
+		}
+		fmt.Fprintf(s.b, "<polygon points=\"%s\"%s/>\n", strings.Join(points, " "), paint)
+	case shape == "plaintext" || shape == "plain" || shape == "none":
+	default:
+		s.rect(c, w, h, style.rounded || shape == "Mrecord", fill, stroke, style)
+	}
+	if shape != "point" {
+		s.text(p, attrs)
+	}
+	fmt.Fprintf(s.b, "%s</g>\n", end)
+}
+
+func (s *svgWriter) edge(index int, e *Edge) {
+	attrs := effectiveEdgeAttributes(e, s.st)
+	style := newExportStyle(attrs, map[string]bool{})
+	if style.invisible {
+		return
+	}
+	sp, ok := parseSpline(attrs["pos"])
+	if !ok {
+		sp, ok = s.straight(e, attrs)
+		if !ok {
+			return
+		}
+	}
+	stroke := firstNonEmpty(style.stroke, "black")
+
+	fmt.Fprintf(s.b, "<g id=\"edge%d\" class=\"edge\">\n<title>%s</title>\n", index, svgEscape(firstNonEmpty(attrString(attrs["tooltip"]), strings.TrimPrefix(e.element(), "edge "))))
+	end := s.link(attrs)
+	d := new(strings.Builder)
+	for i, p := range sp.points {
+		switch {
+		case i == 0:
+			d.WriteString("M")
+		case i == 1:
+			d.WriteString(" C")
+		default:
+			d.WriteString(" ")
+		}
+		d.WriteString(formatPoint(s.at(p)))
+	}
+	fmt.Fprintf(s.b, "<path d=\"%s\" fill=\"none\"%s/>\n", d.String(), svgStroke(stroke, style))
+	if sp.end != nil {
+		s.arrow(sp.points[len(sp.points)-1], *sp.end, attrString(attrs["arrowhead"]), stroke, style)
+	}
+	if sp.start != nil {
+		s.arrow(sp.points[0], *sp.start, attrString(attrs["arrowtail"]), stroke, style)
+	}
+	if lp, ok := parseFloats(attrs["lp"]); ok && len(lp) == 2 {
+		s.text(point{lp[0], lp[1]}, attrs)
+	}
+	fmt.Fprintf(s.b, "%s</g>\n", end)
+}
+
+// straight returns a straight route between the nodes of an edge added
+// after the layout.
+func (s *svgWriter) straight(e *Edge, attrs map[string]interface{}) (spline, bool) {
+	ends := [2]point{}
+	for i, n := range []*Node{e.from, e.to} {
+		nattrs := effectiveNodeAttributes(n, s.st)
+		pos, ok := parseFloats(nattrs["pos"])
+		if !ok || len(pos) < 2 {
+			return spline{}, false
+		}
+		ends[i] = point{pos[0], pos[1]}
+	}
+	if ends[0] == ends[1] {
+		return spline{}, false
+	}
+	for i, n := range []*Node{e.from, e.to} {
+		nattrs := effectiveNodeAttributes(n, s.st)
+		w, _ := numericValue(nattrs["width"])
+		h, _ := numericValue(nattrs["height"])
+		ends[i] = clipToNode(ends[i], w*pointsPerInch, h*pointsPerInch, shapeOf(nattrs), ends[1-i])
+	}
+	dir := edgeDir(e, attrs)
+	return newSpline(ends[:], dir == "back" || dir == "both", dir == "forward" || dir == "both"), true
+}
+
+// arrow draws an arrowhead from the end of an edge to its tip.
+func (s *svgWriter) arrow(base, tip point, name, stroke string, style exportStyle) {
+	if name == "none" {
+		return
+	}
+	b, t := s.at(base), s.at(tip)
+	length := math.Hypot(t.x-b.x, t.y-b.y)
+	if length == 0 {
+		return
+	}
+	u := point{(t.x - b.x) / length, (t.y - b.y) / length}
+	n := point{-u.y * length * 0.35, u.x * length * 0.35}
+	along := func(p point, f float64) point { return point{p.x + u.x*length*f, p.y + u.y*length*f} }
+	side := func(p point, f float64) point { return point{p.x + n.x*f, p.y + n.y*f} }
+
+	open := false
+	if len(name) > 1 && (name[0] == 'l' || name[0] == 'r') {
+		name = name[1:]
+	}
+	if len(name) > 1 && name[0] == 'o' {
+		open, name = true, name[1:]
+	}
+	switch name {
+	case "empty":
+		open, name = true, "normal"
+	case "invempty":
+		open, name = true, "inv"
+	case "invdot", "invodot":
+		open, name = name == "invodot", "dot"
+	}
+	fill := stroke
+	if open {
+		fill = "none"
+	}
+	paint := svgPaint("fill", fill) + svgStroke(stroke, exportStyle{width: style.width, bold: style.bold})
+	polygon := func(points ...point) {
+		all := []string{}
+		for _, p := range points {
+			all = append(all, formatPoint(p))
+		}
+		fmt.Fprintf(s.b, "<polygon points=\"%s\"%s/>\n", strings.Join(all, " "), paint)
+	}
+	switch name {
+	case "inv":
+		polygon(b, side(t, 1), side(t, -1))
+	case "vee":
+		polygon(t, side(b, 1), along(b, 1.0/3), side(b, -1))
+	case "dot":
+		c := along(b, 0.5)
+		fmt.Fprintf(s.b, "<ellipse cx=\"%s\" cy=\"%s\" rx=\"%s\" ry=\"%s\"%s/>\n", formatCoord(c.x), formatCoord(c.y), formatCoord(length/2), formatCoord(length/2), paint)
+	case "diamond":
+		mid := along(b, 0.5)
+		polygon(t, side(mid, 1), b, side(mid, -1))
+	case "box":
+		polygon(side(b, 1), side(t, 1), side(t, -1), side(b, -1))
+	case "tee":
+		polygon(side(along(b, 0.7), 1.4), side(t, 1.4), side(t, -1.4), side(along(b, 0.7), -1.4))
+	default:
+		polygon(t, side(b, 1), side(b, -1))
+	}
+}
+
+func (s *svgWriter) rect(c point, w, h float64, rounded bool, fill, stroke string, style exportStyle) {
+	corner := ""
+	if rounded {
+		corner = fmt.Sprintf(" rx=\"%s\" ry=\"%s\"", formatCoord(math.Min(w, h)/4), formatCoord(math.Min(w, h)/4))
+	}
+	fmt.Fprintf(s.b, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\"%s%s%s/>\n",
+		formatCoord(c.x-w/2), formatCoord(c.y-h/2), formatCoord(w), formatCoord(h), corner, svgPaint("fill", fill), svgStroke(stroke, style))
+}
+
+// text writes the lines of the label of an element centered at p, in
+// layout coordinates.
+func (s *svgWriter) text(p point, attrs map[string]interface{}) {
+	lines := labelLines(attrs["label"])
+	if len(lines) == 0 {
+		return
+	}
+	size, name := fontOf(attrs)
+	font := fmt.Sprintf(" font-family=\"%s\" font-size=\"%s\"", svgEscape(svgFontFamily(name)), formatCoord(size))
+	lower := strings.ToLower(name)
+	if strings.Contains(lower, "bold") {
+		font += " font-weight=\"bold\""
+	}
+	if strings.Contains(lower, "italic") || strings.Contains(lower, "oblique") {
+		font += " font-style=\"italic\""
+	}
+	if color := attrString(attrs["fontcolor"]); len(color) > 0 {
+		font += svgPaint("fill", color)
+	}
+	c := s.at(p)
+	lineHeight := size * 1.2
+	top := c.y - lineHeight*float64(len(lines))/2
+	for i, line := range lines {
+		y := top + lineHeight*(float64(i)+0.5) + size*0.3
+		fmt.Fprintf(s.b, "<text text-anchor=\"middle\" x=\"%s\" y=\"%s\"%s>%s</text>\n", formatCoord(c.x), formatCoord(y), font, svgEscape(line))
+	}
+}
+
+// link opens an anchor for an element with a URL and returns its closing tag.
+func (s *svgWriter) link(attrs map[string]interface{}) string {
+	url := firstNonEmpty(attrString(attrs["URL"]), attrString(attrs["href"]))
+	if len(url) == 0 {
+		return ""
+	}
+	title := ""
+	if tooltip := attrString(attrs["tooltip"]); len(tooltip) > 0 {
+		title = fmt.Sprintf(" xlink:title=\"%s\"", svgEscape(tooltip))
+	}
+	target := ""
+	if t := attrString(attrs["target"]); len(t) > 0 {
+		target = fmt.Sprintf(" target=\"%s\"", svgEscape(t))
+	}
+	fmt.Fprintf(s.b, "<a xlink:href=\"%s\"%s%s>\n", svgEscape(url), title, target)
+	return "</a>\n"
+}
+
+// svgStroke returns the stroke attributes of an outline or line.
+func svgStroke(color string, style exportStyle) string {
+	res := svgPaint("stroke", color)
+	width := style.width
+	if width == 0 && style.bold {
+		width = 2
+	}
+	if width > 0 && width != 1 {
+		res += fmt.Sprintf(" stroke-width=\"%s\"", formatCoord(width))
+	}
+	switch {
+	case style.dashed:
+		res += " stroke-dasharray=\"5,2\""
+	case style.dotted:
+		res += " stroke-dasharray=\"1,5\""
+	}
+	return res
+}
+
+// svgPaint returns a fill or stroke attribute for a dot color, with its
+// opacity when the color has an alpha channel.
+func svgPaint(property, color string) string {
+	c, opacity := svgColor(color)
+	res := fmt.Sprintf(" %s=\"%s\"", property, svgEscape(c))
+	if opacity < 1 {
+		res += fmt.Sprintf(" %s-opacity=\"%s\"", property, formatCoord(opacity))
+	}
+	return res
+}
+
+// svgColor converts a dot color: the first of a color list, without
+// color scheme, "#rrggbbaa" split in color and opacity and "H,S,V" (or
+// "H S V") converted to RGB.
+func svgColor(color string) (string, float64) {
+	color = strings.TrimSpace(strings.SplitN(color, ":", 2)[0])
+	color = strings.SplitN(color, ";", 2)[0]
+	if strings.HasPrefix(color, "/") {
+		color = color[strings.LastIndex(color, "/")+1:]
+	}
+	switch {
+	case color == "transparent" || color == "none" || len(color) == 0:
+		return "none", 1
+	case strings.HasPrefix(color, "#") && len(color) == 9:
+		alpha, err := strconv.ParseUint(color[7:], 16, 8)
+		if err == nil {
+			return color[:7], float64(alpha) / 255
+		}
+	case strings.ContainsAny(color[:1], "0123456789."):
+		hsv := strings.FieldsFunc(color, func(r rune) bool { return r == ',' || r == ' ' })
+		if len(hsv) == 3 {
+			values := [3]float64{}
+			for i, each := range hsv {
+				f, err := strconv.ParseFloat(each, 64)
+				if err != nil {
+					return color, 1
+				}
+				values[i] = math.Max(0, math.Min(1, f))
+			}
+			return hsvToRGB(values[0], values[1], values[2]), 1
+		}
+	}
+	return color, 1
+}
+
+func hsvToRGB(h, s, v float64) string {
+	i := math.Floor(h * 6)
+	f := h*6 - i
+	p, q, t := v*(1-s), v*(1-f*s), v*(1-(1-f)*s)
+	var r, g, b float64
+	switch int(i) % 6 {
+	case 0:
+		r, g, b = v, t, p
+	case 1:
+		r, g, b = q, v, p
+	case 2:
+		r, g, b = p, v, t
+	case 3:
+		r, g, b = p, q, v
+	case 4:
+		r, g, b = t, p, v
+	default:
+		r, g, b = v, p, q
+	}
+	return fmt.Sprintf("#%02x%02x%02x", int(math.Round(r*255)), int(math.Round(g*255)), int(math.Round(b*255)))
+}
+
+// svgFontFamily maps the standard PostScript font names to CSS families.
+func svgFontFamily(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "times"):
+		return "Times,serif"
+	case strings.Contains(lower, "helvetica") || strings.Contains(lower, "arial"):
+		return "Helvetica,sans-Serif"
+	case strings.Contains(lower, "courier"):
+		return "Courier,monospace"
+	}
+	return name
+}
+
+var svgEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+func svgEscape(s string) string {
+	return svgEscaper.Replace(s)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, each := range values {
+		if len(each) > 0 {
+			return each
+		}
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package dot
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// svgWellFormed reads all the XML tokens of the output.
+func svgWellFormed(t *testing.T, out []byte) {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(out))
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("got [%v] in [%s]", err, out)
+		}
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	g := NewGraph(Directed)
+	g.Attr("label", "Title")
+	sub := g.NewSubgraph()
+	sub.Attr("style", "filled")
+	sub.Attr("fillcolor", "#ff000080")
+	a := g.NodeWithID("a", WithLabel("a & b"), func(a *AttributesMap) {
+		a.Attr("shape", "box")
+		a.Attr("style", "rounded,filled")
+		a.Attr("URL", "https://example.com")
+		a.Attr("tooltip", "first")
+	})
+	b := sub.NodeWithID("b", func(a *AttributesMap) { a.Attr("shape", "diamond") })
+	g.Edge(a, b, WithLabel("go"), func(a *AttributesMap) { a.Attr("style", "dashed") })
+	g.Edge(b, a, func(a *AttributesMap) { a.Attr("arrowhead", "odot") })
+	g.LayeredLayout()
+	buf := new(bytes.Buffer)
+	if err := g.WriteSVG(buf); err != nil {
+		t.Fatal(err)
+	}
+	svgWellFormed(t, buf.Bytes())
+	out := buf.String()
+	for _, want := range []string{
+		`<g id="clust1" class="cluster">`,
+		`fill="#ff0000" fill-opacity="0.5"`,
+		`<a xlink:href="https://example.com" xlink:title="first">`,
+		`<title>first</title>`,
+		`rx="9" ry="9" fill="lightgrey" stroke="black"/>`,
+		`>a &amp; b</text>`,
+		`>go</text>`,
+		`>Title</text>`,
+		`>cluster_1</text>`,
+		`stroke-dasharray="5,2"`,
+		`<title>b-&gt;a</title>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("got [%s] want [%s]", out, want)
+		}
+	}
+	if got, want := strings.Count(out, "<polygon"), 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := strings.Count(out, "<ellipse"), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestWriteSVGWithoutLayout(t *testing.T) {
+	g := NewGraph(Directed)
+	g.Edge(g.Node(), g.Node())
+	if err := g.WriteSVG(new(bytes.Buffer)); err == nil {
+		t.Errorf("got [%v] want an error", err)
+	}
+	g.LayeredLayout()
+	n := g.Node()
+	if err := g.WriteSVG(new(bytes.Buffer)); err == nil {
+		t.Errorf("got [%v] want an error", err)
+	}
+	n.Attr("pos", "10")
+	if err := g.WriteSVG(new(bytes.Buffer)); err == nil {
+		t.Errorf("got [%v] want an error", err)
+	}
+}
+
+func TestWriteSVGStraightEdge(t *testing.T) {
+	g := NewGraph(Undirected)
+	a, b := g.Node(), g.Node()
+	g.CircularLayout()
+	g.Edge(a, b, func(a *AttributesMap) { a.Attr("color", "blue") })
+	b.Attr("style", "invis")
+	buf := new(bytes.Buffer)
+	if err := g.WriteSVG(buf); err != nil {
+		t.Fatal(err)
+	}
+	svgWellFormed(t, buf.Bytes())
+	out := buf.String()
+	if !strings.Contains(out, `fill="none" stroke="blue"/>`) {
+		t.Errorf("got [%s] want a blue edge", out)
+	}
+	if got, want := strings.Count(out, `class="node"`), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestSVGColor(t *testing.T) {
+	for _, each := range []struct {
+		in, color string
+		opacity   float64
+	}{
+		{"red", "red", 1},
+		{"red:blue", "red", 1},
+		{"/x11/blue", "blue", 1},
+		{"#00ff0040", "#00ff00", 64.0 / 255},
+		{"0.5 1 1", "#00ffff", 1},
+		{"0,1,0.5", "#800000", 1},
+		{"transparent", "none", 1},
+	} {
+		color, opacity := svgColor(each.in)
+		if color != each.color || opacity != each.opacity {
+			t.Errorf("%s: got [%v %v] want [%v %v]", each.in, color, opacity, each.color, each.opacity)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package dot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteTGF writes the graph in Trivial Graph Format: one line per node with
+// its sequence number and label, a # separator, then one line per edge with
+// the sequence numbers of its nodes and its label, if any. Attributes other
+// than the label and subgraphs cannot be represented.
+func (g *Graph) WriteTGF(w io.Writer) error {
+	b := new(strings.Builder)
+	for _, n := range g.allNodes() {
+		fmt.Fprintf(b, "%d %s\n", n.seq, tgfLabel(n.Value("label"), n.id))
+	}
+	fmt.Fprintln(b, "#")
+	for _, e := range g.allEdges() {
+		line := fmt.Sprintf("%d %d %s", e.from.seq, e.to.seq, tgfLabel(e.Value("label"), ""))
+		fmt.Fprintln(b, strings.TrimRight(line, " "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// tgfLabel returns the label on a single line, or fallback if it is not plain text.
+func tgfLabel(v interface{}, fallback string) string {
+	label, ok := plainLabel(v)
+	if !ok || len(label) == 0 {
+		return fallback
+	}
+	return strings.Replace(label, "\n", " ", -1)
+}
@@ -0,0 +1,23 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteTGF(t *testing.T) {
+	g := NewGraph(Directed)
+	a := g.Node(WithLabel("first\nnode"))
+	b := g.NewSubgraph().Node(WithLabel("second"))
+	g.Edge(a, b, WithLabel("go"))
+	g.Edge(b, a)
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteTGF(buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "1 first node\n3 second\n#\n1 3 go\n3 1\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package dot
+
+import "fmt"
+
+// Theme is a set of default attributes for the graph, its nodes, its edges
+// and its clusters, together with a palette of fill colors matching it.
+type Theme struct {
+	Name    string
+	Graph   map[string]string
+	Node    map[string]string
+	Edge    map[string]string
+	Cluster map[string]string
+	Palette Palette
+}
+
+var (
+	// LightTheme is a soft theme on a white background.
+	LightTheme = Theme{
+		Name:    "light",
+		Graph:   map[string]string{"bgcolor": "#ffffff", "fontname": "Helvetica", "fontcolor": "#222222"},
+		Node:    map[string]string{"shape": "box", "style": "rounded,filled", "fillcolor": "#f5f5f5", "color": "#666666", "fontname": "Helvetica", "fontcolor": "#222222"},
+		Edge:    map[string]string{"color": "#666666", "fontname": "Helvetica", "fontcolor": "#444444"},
+		Cluster: map[string]string{"style": "rounded", "color": "#bbbbbb", "fontcolor": "#444444"},
+		Palette: PastelPalette,
+	}
+	// DarkTheme is a light on dark theme.
+	DarkTheme = Theme{
+		Name:    "dark",
+		Graph:   map[string]string{"bgcolor": "#1e1e1e", "fontname": "Helvetica", "fontcolor": "#dddddd"},
+		Node:    map[string]string{"shape": "box", "style": "rounded,filled", "fillcolor": "#2d2d2d", "color": "#888888", "fontname": "Helvetica", "fontcolor": "#eeeeee"},
+		Edge:    map[string]string{"color": "#aaaaaa", "fontname": "Helvetica", "fontcolor": "#cccccc"},
+		Cluster: map[string]string{"style": "rounded", "color": "#555555", "fontcolor": "#cccccc"},
+		Palette: DarkPalette,
+	}
+	// HighContrastTheme maximizes contrast with thick lines.
+	HighContrastTheme = Theme{
+		Name:    "high-contrast",
+		Graph:   map[string]string{"bgcolor": "#000000", "fontname": "Helvetica-Bold", "fontcolor": "#ffffff"},
+		Node:    map[string]string{"shape": "box", "style": "filled", "fillcolor": "#000000", "color": "#ffff00", "penwidth": "2", "fontname": "Helvetica-Bold", "fontcolor": "#ffffff"},
+		Edge:    map[string]string{"color": "#ffff00", "penwidth": "2", "fontname": "Helvetica-Bold", "fontcolor": "#ffffff"},
+		Cluster: map[string]string{"color": "#ffffff", "penwidth": "2", "fontcolor": "#ffffff"},
+		Palette: HighContrastPalette,
+	}
+	// GrayscaleTheme is suited for printing.
+	GrayscaleTheme = Theme{
+		Name:    "grayscale",
+		Graph:   map[string]string{"bgcolor": "#ffffff", "fontname": "Times-Roman", "fontcolor": "#000000"},
+		Node:    map[string]string{"shape": "box", "style": "filled", "fillcolor": "#ffffff", "color": "#000000", "fontname": "Times-Roman", "fontcolor": "#000000"},
+		Edge:    map[string]string{"color": "#000000", "fontname": "Times-Roman", "fontcolor": "#000000"},
+		Cluster: map[string]string{"style": "dashed", "color": "#777777", "fontcolor": "#000000"},
+		Palette: GrayscalePalette,
+	}
+	// ColorblindTheme uses colors distinguishable with the common forms of color blindness.
+	ColorblindTheme = Theme{
+		Name:    "colorblind",
+		Graph:   map[string]string{"bgcolor": "#ffffff", "fontname": "Helvetica", "fontcolor": "#000000"},
+		Node:    map[string]string{"shape": "box", "style": "rounded,filled", "fillcolor": "#ffffff", "color": "#0072b2", "fontname": "Helvetica", "fontcolor": "#000000"},
+		Edge:    map[string]string{"color": "#0072b2", "fontname": "Helvetica", "fontcolor": "#000000"},
+		Cluster: map[string]string{"style": "rounded", "color": "#e69f00", "fontcolor": "#000000"},
+		Palette: ColorblindPalette,
+	}
+)
+
+// Themes returns all the built-in themes.
+func Themes() []Theme {
+	return []Theme{LightTheme, DarkTheme, HighContrastTheme, GrayscaleTheme, ColorblindTheme}
+}
+
+// ApplyTheme sets the theme attributes on the top-level graph, on the node
+// and edge global attributes and on every subgraph. Attributes already set,
+// there or on nodes and edges, are kept.
+func (g *Graph) ApplyTheme(t Theme) *Graph {
+	root := g.Root()
+	setUnset(&root.AttributesMap, t.Graph)
+	setUnset(&root.nodeAttrs, t.Node)
+	setUnset(&root.edgeAttrs, t.Edge)
+	root.walkGraphs(func(each *Graph) {
+		if each != root {
+			setUnset(&each.AttributesMap, t.Cluster)
+		}
+	})
+	return g
+}
+
+// setUnset sets the attributes that have no value yet.
+func setUnset(a *AttributesMap, values map[string]string) {
+	for k, v := range values {
+		if a.Value(k) == nil {
+			a.Attr(k, v)
+		}
+	}
+}
+
+// Palette is a list of distinct colors.
+type Palette []string
+
+var (
+	// CategoryPalette is the Tableau 10 palette.
+	CategoryPalette = Palette{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac"}
+	// ColorblindPalette is the Okabe-Ito palette.
+	ColorblindPalette = Palette{"#e69f00", "#56b4e9", "#009e73", "#f0e442", "#0072b2", "#d55e00", "#cc79a7", "#999999"}
+	// PastelPalette has light colors for dark text.
+	PastelPalette = Palette{"#fbb4ae", "#b3cde3", "#ccebc5", "#decbe4", "#fed9a6", "#ffffcc", "#e5d8bd", "#fddaec"}
+	// DarkPalette has dark colors for light text.
+	DarkPalette = Palette{"#1b4965", "#5c2a2a", "#2d4a2b", "#4a3b5c", "#5c4a1e", "#2b4a4a", "#4a2b3e", "#3b3b3b"}
+	// HighContrastPalette has saturated colors for light text on black.
+	HighContrastPalette = Palette{"#0000ff", "#008000", "#800080", "#a52a2a", "#008080", "#000080"}
+	// GrayscalePalette has gray levels for dark text.
+	GrayscalePalette = Palette{"#f0f0f0", "#d9d9d9", "#bdbdbd", "#e8e8e8", "#cccccc", "#aaaaaa"}
+)
+
+// Color returns the i-th color, cycling when the palette is exhausted.
+func (p Palette) Color(i int) string {
+	if len(p) == 0 {
+		return ""
+	}
+	return p[i%len(p)]
+}
+
+// ColorSubgraphs fills each subgraph, in identifier order and at every depth,
+// with a distinct color of the palette.
+func (g *Graph) ColorSubgraphs(p Palette) {
+	i := 0
+	g.walkGraphs(func(each *Graph) {
+		if each == g {
+			return
+		}
+		appendStyle(&each.AttributesMap, "filled")
+		each.Attr("fillcolor", p.Color(i))
+		i++
+	})
+}
+
+// ColorNodesBy fills each node with a color of the palette chosen by the value
+// of its categorical attribute (e.g. "team"), assigning colors to values in
+// order of appearance. Nodes without the attribute are left untouched.
+// It returns the color assigned to each value, e.g. to build a legend.
+func (g *Graph) ColorNodesBy(attr string, p Palette) map[string]string {
+	colors := map[string]string{}
+	for _, n := range g.allNodes() {
+		v := n.Value(attr)
+		if v == nil {
+			continue
+		}
+		category := fmt.Sprint(v)
+		color, ok := colors[category]
+		if !ok {
+			color = p.Color(len(colors))
+			colors[category] = color
+		}
+		appendStyle(n.Attrs(), "filled")
+		n.Attr("fillcolor", color)
+	}
+	return colors
+}
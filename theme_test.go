@@ -0,0 +1,82 @@
+package dot
+
+import "testing"
+
+func TestApplyTheme(t *testing.T) {
+	g := NewGraph(Directed)
+	a := g.Node(WithLabel("a"))
+	sub := g.NewSubgraph()
+	sub.Attr("color", "red")
+	b := sub.Node(WithLabel("b"))
+	g.Edge(a, b)
+	g.Attr("bgcolor", "navy")
+	g.NodeBaseAttrs().Attr("shape", "circle")
+	g.ApplyTheme(DarkTheme)
+
+	if got, want := g.Value("bgcolor"), "navy"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := g.Value("fontcolor"), "#dddddd"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := g.NodeBaseAttrs().Value("shape"), "circle"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := g.NodeBaseAttrs().Value("fillcolor"), "#2d2d2d"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := g.EdgeBaseAttrs().Value("color"), "#aaaaaa"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := sub.Value("color"), "red"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := sub.Value("fontcolor"), "#cccccc"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestEdgeBaseAttrs(t *testing.T) {
+	g := NewGraph(Directed)
+	g.EdgeBaseAttrs().Attr("color", "blue")
+	if got, want := flatten(g.String()), `digraph  {edge[color="blue"]}`; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestColorSubgraphs(t *testing.T) {
+	g := NewGraph(Directed)
+	one := g.NewSubgraph()
+	two := g.NewSubgraph()
+	two.Attr("style", "rounded")
+	g.ColorSubgraphs(Palette{"#111111", "#222222"})
+
+	if got, want := one.Value("fillcolor"), "#111111"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := two.Value("style"), "rounded,filled"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestColorNodesBy(t *testing.T) {
+	g := NewGraph(Directed)
+	a := g.Node(func(a *AttributesMap) { a.Attr("team", "core") })
+	b := g.Node(func(a *AttributesMap) { a.Attr("team", "web") })
+	c := g.Node(func(a *AttributesMap) { a.Attr("team", "core") })
+	d := g.Node()
+
+	legend := g.ColorNodesBy("team", Palette{"#111111", "#222222"})
+	if got, want := len(legend), 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := a.Value("fillcolor"), c.Value("fillcolor"); got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := b.Value("fillcolor"), "#222222"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got := d.Value("fillcolor"); got != nil {
+		t.Errorf("got [%v] want [%v]", got, nil)
+	}
+}
@@ -0,0 +1,171 @@
+package dot
+
+import (
+	"container/heap"
+	"fmt"
+	"strings"
+)
+
+// CycleError is returned when an operation requires an acyclic graph.
+type CycleError struct {
+	Cycles [][]*Node
+}
+
+func (e *CycleError) Error() string {
+	parts := make([]string, len(e.Cycles))
+	for i, cycle := range e.Cycles {
+		ids := make([]string, len(cycle)+1)
+		for j, n := range cycle {
+			ids[j] = n.id
+		}
+		ids[len(cycle)] = cycle[0].id
+		parts[i] = strings.Join(ids, " -> ")
+	}
+	return fmt.Sprintf("graph has %d cycle(s): %s", len(e.Cycles), strings.Join(parts, "; "))
+}
+
+// TopologicalSort returns all the nodes, including the ones in subgraphs,
+// ordered so that every edge goes from an earlier node to a later one.
+// Among the nodes ready at the same time the one created first wins,
+// so the order is deterministic. The edges of an Undirected graph are
+// treated as directed, from the first node given to Edge to the second.
+// If the graph is not acyclic a *CycleError is returned.
+func (g *Graph) TopologicalSort() ([]*Node, error) {
+	adj := newAdjacency(g)
+	order, ok := adj.topologicalOrder()
+	if !ok {
+		return nil, &CycleError{Cycles: adj.nodeCycles()}
+	}
+	return adj.nodesAt(order), nil
+}
+
+// FindCycles returns the cycles found with a depth-first search, one for
+// each edge closing a cycle, as lists of nodes in edge order.
+// Self loops are returned as single node cycles. An acyclic graph
+// returns an empty slice. As in TopologicalSort, the edges of an
+// Undirected graph are treated as directed.
+func (g *Graph) FindCycles() [][]*Node {
+	return newAdjacency(g).nodeCycles()
+}
+
+// HighlightCycles applies the given attributes to all edges along the
+// cycles (as returned by FindCycles). Without attributes, cycle edges
+// are painted red and bold.
+func (g *Graph) HighlightCycles(cycles [][]*Node, withAttrs ...func(*AttributesMap)) {
+	if len(withAttrs) == 0 {
+		withAttrs = []func(*AttributesMap){func(a *AttributesMap) {
+			a.Attr("color", "red")
+			a.Attr("penwidth", "2")
+		}}
+	}
+	adj := newAdjacency(g)
+	for _, cycle := range cycles {
+		for i, n := range cycle {
+			u := adj.nodeIndex(n)
+			v := adj.nodeIndex(cycle[(i+1)%len(cycle)])
+			if u < 0 || v < 0 {
+				continue
+			}
+			for _, e := range adj.edgesBetween(u, v) {
+				for _, op := range withAttrs {
+					op(adj.edges[e].Attrs())
+				}
+			}
+		}
+	}
+}
+
+// topologicalOrder runs Kahn's algorithm picking the smallest ready index
+// first. It reports false if some node is part of a cycle.
+func (adj *adjacency) topologicalOrder() ([]int, bool) {
+	indegree := make([]int, len(adj.nodes))
+	for _, v := range adj.to {
+		indegree[v]++
+	}
+
+	ready := &intHeap{}
+	for i, d := range indegree {
+		if d == 0 {
+			heap.Push(ready, i)
+		}
+	}
+
+	order := make([]int, 0, len(adj.nodes))
+	for ready.Len() > 0 {
+		u := heap.Pop(ready).(int)
+		order = append(order, u)
+		for _, e := range adj.out[u] {
+			v := adj.to[e]
+			indegree[v]--
+			if indegree[v] == 0 {
+				heap.Push(ready, v)
+			}
+		}
+	}
+	return order, len(order) == len(adj.nodes)
+}
+
+// cycles returns, for each back edge of a depth-first search, the cycle it closes.
+func (adj *adjacency) cycles() [][]int {
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := make([]int, len(adj.nodes))
+	stack := []int{}
+	found := [][]int{}
+
+	var visit func(u int)
+	visit = func(u int) {
+		state[u] = onStack
+		stack = append(stack, u)
+		for _, v := range adj.successors(u) {
+			switch state[v] {
+			case unvisited:
+				visit(v)
+			case onStack:
+				start := len(stack) - 1
+				for stack[start] != v {
+					start--
+				}
+				cycle := make([]int, len(stack)-start)
+				copy(cycle, stack[start:])
+				found = append(found, cycle)
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[u] = done
+	}
+
+	for u := range adj.nodes {
+		if state[u] == unvisited {
+			visit(u)
+		}
+	}
+	return found
+}
+
+func (adj *adjacency) nodeCycles() [][]*Node {
+	cycles := adj.cycles()
+	res := make([][]*Node, len(cycles))
+	for i, each := range cycles {
+		res[i] = adj.nodesAt(each)
+	}
+	return res
+}
+
+// intHeap is a min-heap of ints.
+type intHeap []int
+
+func (h intHeap) Len() int            { return len(h) }
+func (h intHeap) Less(i, j int) bool  { return h[i] < h[j] }
+func (h intHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *intHeap) Push(x interface{}) { *h = append(*h, x.(int)) }
+func (h *intHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
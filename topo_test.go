@@ -0,0 +1,83 @@
+package dot
+
+import (
+	"strings"
+	"testing"
+)
+
+func nodeIDs(nodes []*Node) string {
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID()
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestTopologicalSort(t *testing.T) {
+	g := NewGraph(Directed)
+	deploy := g.NodeWithID("deploy")
+	build := g.NodeWithID("build")
+	sub := g.NewSubgraph()
+	test := sub.NodeWithID("test")
+	lint := sub.NodeWithID("lint")
+	g.Edge(build, test)
+	g.Edge(lint, deploy)
+	g.Edge(test, deploy)
+
+	order, err := g.TopologicalSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := nodeIDs(order), "build,test,lint,deploy"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestTopologicalSortCycle(t *testing.T) {
+	g := NewGraph(Directed)
+	a := g.NodeWithID("a")
+	b := g.NodeWithID("b")
+	c := g.NodeWithID("c")
+	g.Edge(a, b)
+	g.Edge(b, c)
+	g.Edge(c, a)
+
+	_, err := g.TopologicalSort()
+	cerr, ok := err.(*CycleError)
+	if !ok {
+		t.Fatalf("got [%v] want *CycleError", err)
+	}
+	if got, want := cerr.Error(), "graph has 1 cycle(s): a -> b -> c -> a"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestFindCycles(t *testing.T) {
+	g := NewGraph(Directed)
+	a := g.NodeWithID("a")
+	b := g.NodeWithID("b")
+	c := g.NodeWithID("c")
+	g.Edge(a, b)
+	g.Edge(b, a)
+	g.Edge(c, c)
+	g.Edge(b, c)
+
+	cycles := g.FindCycles()
+	if got, want := len(cycles), 2; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := nodeIDs(cycles[0]), "a,b"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := nodeIDs(cycles[1]), "c"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	g.HighlightCycles(cycles)
+	if got, want := g.FindEdges(*a, *b)[0].Value("color"), "red"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got := g.FindEdges(*b, *c)[0].Value("color"); got != nil {
+		t.Errorf("got [%v] want [%v]", got, nil)
+	}
+}
@@ -0,0 +1,146 @@
+package dot
+
+// TransitiveReduction finds the edges implied by longer paths, the ones whose
+// removal does not change reachability, together with duplicate parallel edges.
+// Without attributes the redundant edges are removed from the graph and copies
+// of them, detached from the graph, are returned; otherwise they are kept and
+// the `withAttrs` functions are applied to them, e.g. to set style=invis or
+// style=dotted so that the layout is preserved.
+// The graph must be acyclic, otherwise a *CycleError is returned.
+func (g *Graph) TransitiveReduction(withAttrs ...func(*AttributesMap)) ([]*Edge, error) {
+	adj := newAdjacency(g)
+	order, ok := adj.topologicalOrder()
+	if !ok {
+		return nil, &CycleError{Cycles: adj.nodeCycles()}
+	}
+	reach := adj.reachability(order)
+
+	redundant := []*Edge{}
+	for u := range adj.nodes {
+		succ := adj.successors(u)
+		seen := map[int]bool{}
+		for _, e := range adj.out[u] {
+			v := adj.to[e]
+			implied := seen[v]
+			for _, w := range succ {
+				if implied {
+					break
+				}
+				implied = w != v && reach[w][v]
+			}
+			seen[v] = true
+			if implied {
+				redundant = append(redundant, adj.edges[e])
+			}
+		}
+	}
+
+	if len(withAttrs) == 0 {
+		g.deleteEdges(redundant)
+		detached := make([]*Edge, len(redundant))
+		for i, e := range redundant {
+			copied := *e
+			copied.AttributesMap = e.AttributesMap.clone()
+			detached[i] = &copied
+		}
+		return detached, nil
+	}
+	for _, e := range redundant {
+		for _, op := range withAttrs {
+			op(e.Attrs())
+		}
+	}
+	return redundant, nil
+}
+
+// TransitiveClosure adds an edge from every node to each node it can reach
+// that is not already a direct successor (self loops excluded), applying the
+// `withAttrs` functions to the new edges. It returns the added edges.
+func (g *Graph) TransitiveClosure(withAttrs ...func(*AttributesMap)) []*Edge {
+	adj := newAdjacency(g)
+	added := []*Edge{}
+	for u := range adj.nodes {
+		direct := map[int]bool{}
+		for _, v := range adj.successors(u) {
+			direct[v] = true
+		}
+		for _, v := range adj.reachableFrom(u) {
+			if v == u || direct[v] {
+				continue
+			}
+			added = append(added, g.Edge(adj.nodes[u], adj.nodes[v], withAttrs...))
+		}
+	}
+	return added
+}
+
+// reachability returns, for each node, the set of nodes reachable through at
+// least one edge. It requires a topological order.
+func (adj *adjacency) reachability(order []int) [][]bool {
+	reach := make([][]bool, len(adj.nodes))
+	for i := len(order) - 1; i >= 0; i-- {
+		u := order[i]
+		reach[u] = make([]bool, len(adj.nodes))
+		for _, v := range adj.successors(u) {
+			reach[u][v] = true
+			for w, ok := range reach[v] {
+				if ok {
+					reach[u][w] = true
+				}
+			}
+		}
+	}
+	return reach
+}
+
+// reachableFrom returns the nodes reachable from u through at least one edge,
+// ordered by index.
+func (adj *adjacency) reachableFrom(u int) []int {
+	seen := make([]bool, len(adj.nodes))
+	queue := adj.successors(u)
+	for _, v := range queue {
+		seen[v] = true
+	}
+	for i := 0; i < len(queue); i++ {
+		for _, w := range adj.successors(queue[i]) {
+			if !seen[w] {
+				seen[w] = true
+				queue = append(queue, w)
+			}
+		}
+	}
+	res := []int{}
+	for v, ok := range seen {
+		if ok {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// deleteEdges removes the given edges, as returned by allEdges, from the
+// graph and its subgraphs.
+func (g *Graph) deleteEdges(edges []*Edge) {
+	if len(edges) == 0 {
+		return
+	}
+	doomed := map[*Edge]bool{}
+	for _, e := range edges {
+		doomed[e] = true
+	}
+	g.walkGraphs(func(each *Graph) {
+		for from, all := range each.edgesFrom {
+			kept := []Edge{}
+			for i := range all {
+				if !doomed[&all[i]] {
+					kept = append(kept, all[i])
+				}
+			}
+			if len(kept) == 0 {
+				delete(each.edgesFrom, from)
+				continue
+			}
+			each.edgesFrom[from] = kept
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package dot
+
+import "testing"
+
+func TestTransitiveReduction(t *testing.T) {
+	g := NewGraph(Directed)
+	a := g.Node(WithLabel("a"))
+	b := g.Node(WithLabel("b"))
+	c := g.Node(WithLabel("c"))
+	g.Edge(a, b)
+	g.Edge(b, c)
+	g.Edge(a, c)
+	g.Edge(a, c)
+
+	removed, err := g.TransitiveReduction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(removed), 2; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	for _, e := range removed {
+		e.Attr("color", "red")
+		for _, each := range g.allEdges() {
+			if each.Value("color") != nil {
+				t.Errorf("got [%v] still in the graph", e)
+			}
+		}
+	}
+	if got, want := flatten(g.String()), `digraph  {n3[label="c"];n2[label="b"];n1[label="a"];n2->n3;n1->n2;}`; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestTransitiveReductionKeepEdges(t *testing.T) {
+	g := NewGraph(Directed)
+	a := g.Node(WithLabel("a"))
+	b := g.Node(WithLabel("b"))
+	c := g.Node(WithLabel("c"))
+	g.Edge(a, b)
+	g.Edge(b, c)
+	g.Edge(a, c)
+
+	if _, err := g.TransitiveReduction(func(a *AttributesMap) { a.Attr("style", "invis") }); err != nil {
+		t.Fatal(err)
+	}
+	edges := g.FindEdges(*a, *c)
+	if got, want := len(edges), 1; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := edges[0].Value("style"), "invis"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestTransitiveReductionCycle(t *testing.T) {
+	g := NewGraph(Directed)
+	a, b, c := g.Node(), g.Node(), g.Node()
+	g.Edge(a, b)
+	g.Edge(b, c)
+	g.Edge(c, a)
+	if _, err := g.TransitiveReduction(); err == nil {
+		t.Error("expected a cycle error")
+	}
+}
+
+func TestTransitiveClosure(t *testing.T) {
+	g := NewGraph(Directed)
+	a := g.Node(WithLabel("a"))
+	b := g.Node(WithLabel("b"))
+	c := g.Node(WithLabel("c"))
+	g.Edge(a, b)
+	g.Edge(b, c)
+
+	added := g.TransitiveClosure(WithLabel("implied"))
+	if got, want := len(added), 1; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	if got, want := len(g.FindEdges(*a, *c)), 1; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
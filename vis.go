@@ -0,0 +1,171 @@
+package dot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// visShapes maps dot shapes to vis.js network node shapes.
+var visShapes = map[string]string{
+	"box":          "box",
+	"rect":         "box",
+	"rectangle":    "box",
+	"square":       "square",
+	"ellipse":      "ellipse",
+	"oval":         "ellipse",
+	"circle":       "circle",
+	"doublecircle": "circle",
+	"point":        "dot",
+	"diamond":      "diamond",
+	"triangle":     "triangle",
+	"invtriangle":  "triangleDown",
+	"hexagon":      "hexagon",
+	"star":         "star",
+	"cylinder":     "database",
+	"plaintext":    "text",
+	"plain":        "text",
+	"none":         "text",
+}
+
+type visNetwork struct {
+	Nodes []visNode `json:"nodes"`
+	Edges []visEdge `json:"edges"`
+}
+
+type visNode struct {
+	ID          string    `json:"id"`
+	Label       string    `json:"label,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Group       string    `json:"group,omitempty"`
+	Shape       string    `json:"shape,omitempty"`
+	Color       *visColor `json:"color,omitempty"`
+	Font        *visFont  `json:"font,omitempty"`
+	BorderWidth float64   `json:"borderWidth,omitempty"`
+	Hidden      bool      `json:"hidden,omitempty"`
+	X           *float64  `json:"x,omitempty"`
+	Y           *float64  `json:"y,omitempty"`
+	Fixed       bool      `json:"fixed,omitempty"`
+}
+
+type visEdge struct {
+	From   string      `json:"from"`
+	To     string      `json:"to"`
+	Label  string      `json:"label,omitempty"`
+	Title  string      `json:"title,omitempty"`
+	Arrows string      `json:"arrows,omitempty"`
+	Dashes interface{} `json:"dashes,omitempty"`
+	Color  *visColor   `json:"color,omitempty"`
+	Font   *visFont    `json:"font,omitempty"`
+	Width  float64     `json:"width,omitempty"`
+	Hidden bool        `json:"hidden,omitempty"`
+}
+
+type visColor struct {
+	Color      string `json:"color,omitempty"`
+	Background string `json:"background,omitempty"`
+	Border     string `json:"border,omitempty"`
+}
+
+type visFont struct {
+	Color string  `json:"color,omitempty"`
+	Size  float64 `json:"size,omitempty"`
+	Face  string  `json:"face,omitempty"`
+}
+
+// WriteVis writes the graph as vis.js network data JSON, an object with
+// "nodes" and "edges" to load in its DataSets. vis.js has no compound
+// nodes: the nodes of a subgraph get its identifier as "group". Shapes,
+// colors, line styles, labels and tooltips are translated. With positions,
+// the `pos` attribute of the nodes (in points, as computed by a layout) is
+// written as "x" and "y", flipping the y axis, and pinned nodes are
+// "fixed". Node identifiers must be unique.
+func (g *Graph) WriteVis(w io.Writer, positions bool) error {
+	st := newStyler(g.Root())
+	network := visNetwork{Nodes: []visNode{}, Edges: []visEdge{}}
+	ids := map[string]bool{}
+	top := 0.0
+	if bb, ok := parseFloats(g.Value("bb")); ok && len(bb) == 4 {
+		top = bb[3]
+	}
+
+	for _, n := range g.allNodes() {
+		if ids[n.id] {
+			return fmt.Errorf("node identifier %q is not unique", n.id)
+		}
+		ids[n.id] = true
+		attrs := effectiveNodeAttributes(n, st)
+		s := newExportStyle(attrs, map[string]bool{})
+		node := visNode{ID: n.id, Title: attrString(attrs["tooltip"]), Hidden: s.invisible}
+		if label, ok := plainLabel(attrs["label"]); ok {
+			node.Label = label
+		}
+		if n.graph != g {
+			node.Group = n.graph.id
+		}
+		node.Shape = visShapes[shapeOf(attrs)]
+		if len(node.Shape) == 0 {
+			node.Shape = "box"
+		}
+		if len(s.fill) > 0 || len(s.stroke) > 0 {
+			node.Color = &visColor{Background: s.fill, Border: s.stroke}
+		}
+		node.Font = newVisFont(s, attrs)
+		node.BorderWidth = s.width
+		if s.bold && s.width == 0 {
+			node.BorderWidth = 2
+		}
+		if positions {
+			if p, pinned, ok := initialPosition(attrs); ok {
+				x, y := p.x, top-p.y
+				node.X, node.Y, node.Fixed = &x, &y, pinned
+			}
+		}
+		network.Nodes = append(network.Nodes, node)
+	}
+
+	for _, e := range g.allEdges() {
+		attrs := effectiveEdgeAttributes(e, st)
+		s := newExportStyle(attrs, map[string]bool{})
+		edge := visEdge{From: e.from.id, To: e.to.id, Title: attrString(attrs["tooltip"]), Width: s.width, Hidden: s.invisible}
+		if label, ok := plainLabel(attrs["label"]); ok {
+			edge.Label = label
+		}
+		switch edgeDir(e, attrs) {
+		case "forward":
+			edge.Arrows = "to"
+		case "back":
+			edge.Arrows = "from"
+		case "both":
+			edge.Arrows = "to, from"
+		}
+		switch {
+		case s.dashed:
+			edge.Dashes = true
+		case s.dotted:
+			edge.Dashes = []int{2, 4}
+		}
+		if len(s.stroke) > 0 {
+			edge.Color = &visColor{Color: s.stroke}
+		}
+		edge.Font = newVisFont(s, attrs)
+		if s.bold && s.width == 0 {
+			edge.Width = 2
+		}
+		network.Edges = append(network.Edges, edge)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(network)
+}
+
+// newVisFont returns the label font of an element, nil when unset.
+func newVisFont(s exportStyle, attrs map[string]interface{}) *visFont {
+	font := visFont{Color: s.font, Face: attrString(attrs["fontname"])}
+	font.Size, _ = numericValue(attrs["fontsize"])
+	if font == (visFont{}) {
+		return nil
+	}
+	return &font
+}
@@ -0,0 +1,83 @@
+package dot
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteVis(t *testing.T) {
+	g := NewGraph(Directed)
+	g.Attr("bb", "0,0,200,100")
+	sub := g.NewSubgraph()
+	a := g.NodeWithID("a", WithLabel(`one\ntwo`), func(a *AttributesMap) {
+		a.Attr("shape", "cylinder")
+		a.Attr("style", "filled")
+		a.Attr("fillcolor", "yellow")
+		a.Attr("tooltip", "first")
+		a.Attr("pos", "10,20!")
+	})
+	b := sub.NodeWithID("b", func(a *AttributesMap) { a.Attr("pos", "30,40") })
+	g.Edge(a, b, WithLabel("go"), func(a *AttributesMap) {
+		a.Attr("style", "dotted")
+		a.Attr("color", "red")
+		a.Attr("dir", "both")
+	})
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteVis(buf, true); err != nil {
+		t.Fatal(err)
+	}
+	network := visNetwork{}
+	if err := json.Unmarshal(buf.Bytes(), &network); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(network.Nodes), 2; got != want {
+		t.Fatalf("got [%v] want [%v]", got, want)
+	}
+	first, second := network.Nodes[0], network.Nodes[1]
+	if got, want := first.Label, "one\ntwo"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := first.Shape, "database"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := first.Color.Background, "yellow"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := first.Title, "first"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := *first.Y, 80.0; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if !first.Fixed || second.Fixed {
+		t.Errorf("got [%v,%v] want [%v,%v]", first.Fixed, second.Fixed, true, false)
+	}
+	if got, want := second.Group, sub.id; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+
+	edge := network.Edges[0]
+	if got, want := edge.Arrows, "to, from"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if got, want := edge.Color.Color, "red"; got != want {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	if _, ok := edge.Dashes.([]interface{}); !ok {
+		t.Errorf("got [%v] want a dash pattern", edge.Dashes)
+	}
+
+	buf.Reset()
+	if err := g.WriteVis(buf, false); err != nil {
+		t.Fatal(err)
+	}
+	network = visNetwork{}
+	if err := json.Unmarshal(buf.Bytes(), &network); err != nil {
+		t.Fatal(err)
+	}
+	if got := network.Nodes[0].X; got != nil {
+		t.Errorf("got [%v] want [%v]", got, nil)
+	}
+}
@@ -0,0 +1,70 @@
+package dot
+
+import "sort"
+
+// graphVisitor receives the parts of a graph from walk. Writers for dot
+// and the other diagram languages implement it.
+type graphVisitor interface {
+	// enterGraph is called for the graph before its subgraphs, nodes and edges.
+	enterGraph(g *Graph)
+	// nodes receives the nodes of the graph, without those of its subgraphs.
+	nodes(g *Graph, nodes []*Node)
+	// edges receives the edges owned by the graph, grouped by tail node.
+	edges(g *Graph, groups [][]*Edge)
+	// sameRank receives the rank groups of the graph, ordered by name.
+	sameRank(g *Graph, groups [][]*Node)
+	// leaveGraph is called once everything in the graph has been visited.
+	leaveGraph(g *Graph)
+}
+
+// walk visits the graph and then, recursively, its subgraphs, nodes, edges
+// and rank groups in the order they are written in dot notation.
+func (g *Graph) walk(v graphVisitor) {
+	v.enterGraph(g)
+
+	for _, key := range g.sortedSubgraphsKeys() {
+		g.subgraphs[key].walk(v)
+	}
+
+	if len(g.nodes) > 0 {
+		nodes := []*Node{}
+		for _, key := range g.sortedNodesKeys() {
+			each := g.nodes[key]
+			nodes = append(nodes, &each)
+		}
+		v.nodes(g, nodes)
+	}
+
+	if len(g.edgesFrom) > 0 {
+		groups := [][]*Edge{}
+		for _, key := range g.sortedEdgesFromKeys() {
+			all := g.edgesFrom[key]
+			group := make([]*Edge, len(all))
+			for i := range all {
+				group[i] = &all[i]
+			}
+			groups = append(groups, group)
+		}
+		v.edges(g, groups)
+	}
+
+	if len(g.sameRank) > 0 {
+		names := make([]string, 0, len(g.sameRank))
+		for name := range g.sameRank {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		groups := [][]*Node{}
+		for _, name := range names {
+			members := g.sameRank[name]
+			group := make([]*Node, len(members))
+			for i := range members {
+				group[i] = &members[i]
+			}
+			groups = append(groups, group)
+		}
+		v.sameRank(g, groups)
+	}
+
+	v.leaveGraph(g)
+}